@@ -0,0 +1,454 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+	"golang.org/x/net/html"
+)
+
+const minContrastRatio = 4.5
+
+var (
+	reCssRule       = regexp.MustCompile(`(?s)([^{}]+)\{([^{}]*)\}`)
+	reCssColor      = regexp.MustCompile(`(?:^|;)\s*color\s*:\s*([^;]+)`)
+	reCssBackground = regexp.MustCompile(`(?:^|;)\s*background(?:-color)?\s*:\s*([^;]+)`)
+	reCssVariable   = regexp.MustCompile(`(?:^|;)\s*(--[\w-]+)\s*:\s*([^;]+)`)
+	reCssHexColor   = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}){1,2}$`)
+	reCssThemeName  = regexp.MustCompile(`data-theme\s*=\s*["']?([\w-]+)`)
+)
+
+// cssTheme is set of css custom properties, default theme is defined on
+// :root and other themes override it.
+type cssTheme struct {
+	name string
+	vars map[string]string
+}
+
+var nonDescriptiveLinks = map[string]bool{
+	"here":        true,
+	"click here":  true,
+	"link":        true,
+	"more":        true,
+	"read more":   true,
+	"this":        true,
+	"this link":   true,
+	"ovde":        true,
+	"klikni ovde": true,
+	"više":        true,
+	"link ovde":   true,
+}
+
+var skipLinkTexts = map[string]string{
+	"bs": "Preskoči na sadržaj",
+	"de": "Zum Inhalt springen",
+	"en": "Skip to content",
+	"es": "Saltar al contenido",
+	"fr": "Aller au contenu",
+	"hr": "Preskoči na sadržaj",
+	"it": "Vai al contenuto",
+	"rs": "Preskoči na sadržaj",
+	"sr": "Preskoči na sadržaj",
+}
+
+type a11yChecker struct {
+	headings []sourceHeading
+	file     string
+	counter  headingCounter
+	heading  headingRef
+	level    int
+	issues   []sourceIssue
+}
+
+func (p *processHtml) postProcessHtmlLang(node *html.Node) {
+	if lang := p.meta.String("lang"); lang != "" {
+		utils.SetHtmlAttribute(node, "lang", lang)
+	}
+}
+
+func (p *processHtml) postProcessHtmlLandmark(node *html.Node) {
+	if _, ok := utils.GetHtmlAttribute(node, "role"); ok {
+		return
+	}
+
+	switch node.Data {
+	case "header":
+		utils.SetHtmlAttribute(node, "role", "banner")
+	case "nav":
+		utils.SetHtmlAttribute(node, "role", "navigation")
+	case "footer":
+		utils.SetHtmlAttribute(node, "role", "contentinfo")
+	default:
+		if utils.GetHtmlId(node) == "author-toc" {
+			utils.SetHtmlAttribute(node, "role", "navigation")
+		}
+	}
+}
+
+func (p *processHtml) postProcessHtmlSkipLink(node *html.Node) {
+	link := &html.Node{
+		Type: html.ElementNode,
+		Data: "a",
+		Attr: []html.Attribute{
+			{Key: "class", Val: "author-skip-link"},
+			{Key: "href", Val: "#author-body"},
+		},
+	}
+	link.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: p.skipLinkText(),
+	})
+
+	node.InsertBefore(link, node.FirstChild)
+}
+
+// skipLinkText returns text of skip link in document language, it can be
+// changed with skip-link-text in front matter.
+func (p *processHtml) skipLinkText() string {
+	if text := p.meta.String("skip-link-text"); text != "" {
+		return text
+	}
+
+	lang := strings.ToLower(p.meta.String("lang"))
+	if idx := strings.IndexAny(lang, "-_"); idx >= 0 {
+		lang = lang[:idx]
+	}
+
+	if text, ok := skipLinkTexts[lang]; ok {
+		return text
+	}
+
+	return skipLinkTexts["en"]
+}
+
+func checkHtmlAccessibility(project *data.Project, node *html.Node) ([]sourceIssue, error) {
+	headings, err := scanSourceHeadings(project.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	c := a11yChecker{
+		headings: headings,
+		counter:  headingCounter{},
+		file:     path.Join(project.OutputFolder, project.Html.OutputFolder, "index.html"),
+	}
+	c.walk(node)
+
+	issues, err := checkTemplateContrast(path.Join(project.OutputFolder, project.Html.OutputFolder), node)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(c.issues, issues...), nil
+}
+
+func (c *a11yChecker) walk(node *html.Node) {
+	if node.Type == html.ElementNode {
+		switch node.Data {
+		case "html":
+			if lang, _ := utils.GetHtmlAttribute(node, "lang"); strings.TrimSpace(lang) == "" {
+				c.report("document has no language, set lang in project file or front matter")
+			}
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level := int(node.Data[1] - '0')
+			c.heading = c.counter.next(utils.GetHtmlText(node))
+			if c.level > 0 && level > c.level+1 {
+				c.report(fmt.Sprintf("heading level skipped from h%d to h%d", c.level, level))
+			}
+			c.level = level
+		case "img":
+			c.checkImg(node)
+		case "table":
+			c.checkTable(node)
+		case "a":
+			c.checkLink(node)
+		}
+	}
+
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		c.walk(n)
+	}
+}
+
+func (c *a11yChecker) report(message string) {
+	c.issues = append(c.issues, newSourceIssue(c.headings, c.file, c.heading, message))
+}
+
+func (c *a11yChecker) checkImg(node *html.Node) {
+	if isHtmlDecorative(node) {
+		return
+	}
+
+	alt, ok := utils.GetHtmlAttribute(node, "alt")
+	if !ok || strings.TrimSpace(alt) == "" {
+		src, _ := utils.GetHtmlAttribute(node, "src")
+		c.report(fmt.Sprintf("image '%s' has no alt text", src))
+	}
+}
+
+func (c *a11yChecker) checkTable(node *html.Node) {
+	if !hasHtmlElement(node, "th") {
+		c.report("table has no header row")
+	}
+}
+
+func (c *a11yChecker) checkLink(node *html.Node) {
+	if isHtmlDecorative(node) {
+		return
+	}
+
+	if label, _ := utils.GetHtmlAttribute(node, "aria-label"); strings.TrimSpace(label) != "" {
+		return
+	}
+
+	text := strings.ToLower(strings.Join(strings.Fields(utils.GetHtmlText(node)), " "))
+	href, _ := utils.GetHtmlAttribute(node, "href")
+
+	if text == "" {
+		if title, _ := utils.GetHtmlAttribute(node, "title"); strings.TrimSpace(title) != "" {
+			return
+		}
+		if hasHtmlElement(node, "img") || hasHtmlElement(node, "i") {
+			return
+		}
+		c.report(fmt.Sprintf("link '%s' has no text", href))
+		return
+	}
+
+	if nonDescriptiveLinks[strings.Trim(text, ".:!")] {
+		c.report(fmt.Sprintf("link '%s' has non-descriptive text '%s'", href, text))
+	}
+}
+
+func isHtmlDecorative(node *html.Node) bool {
+	if role, _ := utils.GetHtmlAttribute(node, "role"); role == "presentation" || role == "none" {
+		return true
+	}
+
+	hidden, _ := utils.GetHtmlAttribute(node, "aria-hidden")
+	return hidden == "true"
+}
+
+func hasHtmlElement(node *html.Node, tag string) bool {
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode && n.Data == tag {
+			return true
+		}
+
+		if hasHtmlElement(n, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkTemplateContrast(dir string, node *html.Node) ([]sourceIssue, error) {
+	var issues []sourceIssue
+
+	for _, stylesheet := range htmlStylesheets(node) {
+		if strings.Contains(stylesheet, ":") || strings.HasSuffix(stylesheet, ".min.css") {
+			continue
+		}
+
+		filePath := path.Join(dir, stylesheet)
+		content, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		css := reCssComment.ReplaceAllString(string(content), "")
+		rules := reCssRule.FindAllStringSubmatch(css, -1)
+		themes := cssThemes(rules)
+
+		for _, rule := range rules {
+			fg := reCssColor.FindStringSubmatch(rule[2])
+			bg := reCssBackground.FindStringSubmatch(rule[2])
+			if fg == nil || bg == nil {
+				continue
+			}
+
+			usesVars := strings.Contains(fg[1], "var(") || strings.Contains(bg[1], "var(")
+			for _, theme := range themes {
+				fgColor, ok := resolveCssColor(fg[1], theme.vars, 0)
+				if !ok {
+					continue
+				}
+				bgColor, ok := resolveCssColor(bg[1], theme.vars, 0)
+				if !ok {
+					continue
+				}
+
+				ratio, ok := contrastRatio(fgColor, bgColor)
+				if ok && ratio < minContrastRatio {
+					message := fmt.Sprintf("low contrast %.2f:1 between %s and %s in '%s'",
+						ratio, fgColor, bgColor, strings.TrimSpace(rule[1]))
+					if usesVars {
+						message += fmt.Sprintf(" with %s theme", theme.name)
+					}
+
+					issues = append(issues, sourceIssue{
+						file:    filePath,
+						message: message,
+					})
+				}
+
+				if !usesVars {
+					break
+				}
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+func cssThemes(rules [][]string) []cssTheme {
+	root := map[string]string{}
+	var overrides []cssTheme
+
+	for _, rule := range rules {
+		vars := reCssVariable.FindAllStringSubmatch(rule[2], -1)
+		if len(vars) == 0 {
+			continue
+		}
+
+		selector := strings.TrimSpace(rule[1])
+		target := root
+		if selector != ":root" && selector != "html" {
+			name := selector
+			if m := reCssThemeName.FindStringSubmatch(selector); m != nil {
+				name = m[1]
+			}
+
+			overrides = append(overrides, cssTheme{name: name, vars: map[string]string{}})
+			target = overrides[len(overrides)-1].vars
+		}
+
+		for _, v := range vars {
+			target[v[1]] = strings.TrimSpace(v[2])
+		}
+	}
+
+	themes := []cssTheme{{name: "default", vars: root}}
+	for _, theme := range overrides {
+		vars := maps.Clone(root)
+		maps.Copy(vars, theme.vars)
+		themes = append(themes, cssTheme{name: theme.name, vars: vars})
+	}
+
+	return themes
+}
+
+// resolveCssColor returns hex color of css value, resolving var() references.
+func resolveCssColor(val string, vars map[string]string, depth int) (string, bool) {
+	val = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(val), "!important"))
+
+	if strings.HasPrefix(val, "var(") && strings.HasSuffix(val, ")") && depth < 8 {
+		name, fallback, _ := strings.Cut(val[4:len(val)-1], ",")
+		if v, ok := vars[strings.TrimSpace(name)]; ok {
+			return resolveCssColor(v, vars, depth+1)
+		}
+		return resolveCssColor(fallback, vars, depth+1)
+	}
+
+	if fields := strings.Fields(val); len(fields) > 0 && reCssHexColor.MatchString(fields[0]) {
+		return fields[0], true
+	}
+
+	return "", false
+}
+
+func htmlStylesheets(node *html.Node) []string {
+	var stylesheets []string
+
+	if node.Type == html.ElementNode && node.Data == "link" {
+		rel, _ := utils.GetHtmlAttribute(node, "rel")
+		href, _ := utils.GetHtmlAttribute(node, "href")
+		if rel == "stylesheet" && href != "" {
+			stylesheets = append(stylesheets, href)
+		}
+	}
+
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		stylesheets = append(stylesheets, htmlStylesheets(n)...)
+	}
+
+	return stylesheets
+}
+
+func contrastRatio(fg, bg string) (float64, bool) {
+	l1, ok := relativeLuminance(fg)
+	if !ok {
+		return 0, false
+	}
+
+	l2, ok := relativeLuminance(bg)
+	if !ok {
+		return 0, false
+	}
+
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+
+	return (l1 + 0.05) / (l2 + 0.05), true
+}
+
+func relativeLuminance(color string) (float64, bool) {
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, false
+	}
+
+	var rgb [3]float64
+	for i := range rgb {
+		val, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return 0, false
+		}
+
+		c := float64(val) / 255
+		if c <= 0.03928 {
+			rgb[i] = c / 12.92
+		} else {
+			rgb[i] = math.Pow((c+0.055)/1.055, 2.4)
+		}
+	}
+
+	return 0.2126*rgb[0] + 0.7152*rgb[1] + 0.0722*rgb[2], true
+}
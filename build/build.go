@@ -30,12 +30,38 @@ import (
 
 const timeout = 30 * time.Second
 
-func BuildProject() {
-	project, err := data.LoadProject("project.json")
+type Config struct {
+	Production       bool
+	KeepIntermediate bool
+	Draft            bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Production:       false,
+		KeepIntermediate: false,
+		Draft:            false,
+	}
+}
+
+func BuildProject(cfg Config) {
+	project, err := data.OpenProject()
 	if err != nil {
 		utils.ExitWithError(err)
 	}
 
+	if cfg.Production {
+		project.Html.Production = true
+	}
+
+	if cfg.KeepIntermediate {
+		project.Pdf.KeepIntermediate = true
+	}
+
+	if cfg.Draft {
+		project.Pdf.Draft = true
+	}
+
 	err = BuildProjectRun(project)
 	if err != nil {
 		utils.ExitWithError(err)
@@ -44,6 +70,19 @@ func BuildProject() {
 }
 
 func BuildProjectRun(project *data.Project) error {
+	if len(project.Languages) > 0 {
+		return buildLanguages(project)
+	}
+
+	return buildTargets(project)
+}
+
+func buildTargets(project *data.Project) error {
+	project, err := expandProject(project)
+	if err != nil {
+		return err
+	}
+
 	for _, target := range project.Targets {
 		switch target {
 		case "html":
@@ -61,3 +100,15 @@ func BuildProjectRun(project *data.Project) error {
 
 	return nil
 }
+
+func expandProject(project *data.Project) (*data.Project, error) {
+	srcs, err := project.ExpandSources()
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := *project
+	expanded.Sources = srcs
+
+	return &expanded, nil
+}
@@ -0,0 +1,385 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+const (
+	defaultPaperThickness = "0.1mm"
+	defaultCoverDpi       = 150
+
+	coverMargin   = 15 * mmToBp
+	minSpineWidth = 6 * mmToBp
+
+	bookInfoFileName = "book.json"
+)
+
+var reHexColor = regexp.MustCompile(`^#?([0-9a-fA-F]{6})$`)
+
+type coverLayout struct {
+	title      string
+	subtitle   string
+	author     string
+	backText   string
+	textColor  string
+	background string
+	width      float64
+	height     float64
+	spine      float64
+	back       bool
+}
+
+// bookInfo is page count and trim size of the book body, recorded before
+// cover and appendix pages are merged into the pdf.
+type bookInfo struct {
+	Pages  int     `json:"pages"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+func BuildCover() {
+	project, err := data.OpenProject()
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	project, err = expandProject(project)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	err = buildCover(project)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	utils.PrintSuccess("Cover generated in " + coverOutputFolder(project))
+}
+
+func coverOutputFolder(project *data.Project) string {
+	return path.Join(project.OutputFolder, "cover")
+}
+
+// recordBookInfo saves page count and trim size of freshly built pdf, so
+// cover spine does not count pages merged into the document later.
+func recordBookInfo(project *data.Project) error {
+	bookPdf := path.Join(project.OutputFolder, project.Pdf.OutputFolder, project.Pdf.OutputFileName)
+
+	pages, width, height, err := pdfPageInfo(bookPdf)
+	if err != nil {
+		return err
+	}
+
+	bleed, err := printBleed(&project.Pdf.Print)
+	if err != nil {
+		return err
+	}
+
+	info := bookInfo{
+		Pages:  pages,
+		Width:  width - 2*bleed,
+		Height: height - 2*bleed,
+	}
+
+	content, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	outDir := coverOutputFolder(project)
+	err = os.MkdirAll(outDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(outDir, bookInfoFileName), content, 0644)
+}
+
+func readBookInfo(project *data.Project) (*bookInfo, error) {
+	infoFile := path.Join(coverOutputFolder(project), bookInfoFileName)
+	if !utils.FileExists(infoFile) {
+		return nil, fmt.Errorf("cover needs page count and size of the book, build pdf first")
+	}
+
+	content, err := os.ReadFile(infoFile)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &bookInfo{}
+	err = json.Unmarshal(content, info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", infoFile, err)
+	}
+
+	return info, nil
+}
+
+func buildCover(project *data.Project) error {
+	cfg := &project.Cover
+	outDir := coverOutputFolder(project)
+	book, bookErr := readBookInfo(project)
+
+	meta, err := loadProjectMetadata(project)
+	if err != nil {
+		return err
+	}
+
+	layout := coverLayout{
+		title:      project.Name,
+		subtitle:   cfg.Subtitle,
+		author:     project.Author,
+		backText:   cfg.BackText,
+		textColor:  cfg.TextColor,
+		background: cfg.Background,
+		back:       cfg.Back,
+	}
+
+	if layout.title == "" {
+		layout.title = meta.String("title")
+	}
+	if layout.subtitle == "" {
+		layout.subtitle = meta.String("subtitle")
+	}
+	if layout.author == "" {
+		layout.author = meta.String("author")
+	}
+	if layout.backText == "" {
+		layout.backText = meta.String("description")
+	}
+	if layout.textColor == "" {
+		layout.textColor = meta.String("titlepage-text-color")
+	}
+	if layout.textColor == "" {
+		layout.textColor = "000000"
+	}
+	if layout.background == "" {
+		layout.background = project.ResolvePath(meta.String("titlepage-background"))
+	}
+
+	m := reHexColor.FindStringSubmatch(layout.textColor)
+	if m == nil {
+		return fmt.Errorf("invalid cover text color '%s', use hex color like FFFFFF", layout.textColor)
+	}
+	layout.textColor = strings.ToUpper(m[1])
+
+	trimSize := cfg.TrimSize
+	if trimSize == "" {
+		trimSize = project.Pdf.Print.TrimSize
+	}
+
+	switch {
+	case trimSize != "":
+		layout.width, layout.height, err = parsePaperSize(trimSize)
+		if err != nil {
+			return err
+		}
+	case bookErr == nil:
+		layout.width, layout.height = book.Width, book.Height
+	default:
+		layout.width, layout.height = paperSizes["a4"][0], paperSizes["a4"][1]
+	}
+
+	if cfg.Spine || cfg.Back {
+		if cfg.Spine {
+			if bookErr != nil {
+				return bookErr
+			}
+
+			layout.spine, err = spineWidth(book.Pages, cfg.PaperThickness)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = renderCover(outDir, "wrap", layout, cfg.Dpi)
+		if err != nil {
+			return err
+		}
+	}
+
+	layout.spine = 0
+	layout.back = false
+
+	return renderCover(outDir, "front", layout, cfg.Dpi)
+}
+
+func spineWidth(pages int, thickness string) (float64, error) {
+	if thickness == "" {
+		thickness = defaultPaperThickness
+	}
+
+	sheet, err := parseLength(thickness)
+	if err != nil {
+		return 0, err
+	}
+
+	leaves := (pages + 1) / 2
+
+	return float64(leaves) * sheet, nil
+}
+
+func renderCover(outDir, name string, layout coverLayout, dpi int) error {
+	dir, err := os.MkdirTemp("", "author-cover-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	background := ""
+	if layout.background != "" {
+		content, err := os.ReadFile(layout.background)
+		if err != nil {
+			return err
+		}
+
+		background = "background" + path.Ext(layout.background)
+		err = os.WriteFile(path.Join(dir, background), content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = runLatex(dir, name, coverSource(layout, background))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(outDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	result, err := os.ReadFile(path.Join(dir, name+".pdf"))
+	if err != nil {
+		return err
+	}
+
+	output := path.Join(outDir, name+".pdf")
+	err = os.WriteFile(output, result, 0644)
+	if err != nil {
+		return err
+	}
+
+	return coverPng(output, dpi)
+}
+
+func coverSource(layout coverLayout, background string) string {
+	// wrap is laid out as back cover, spine and front cover, each optional
+	// except front
+	spineX := 0.0
+	if layout.back {
+		spineX = layout.width
+	}
+	frontX := spineX + layout.spine
+	paperWidth := frontX + layout.width
+
+	var b strings.Builder
+	b.WriteString(latexPreamble())
+	b.WriteString("\\documentclass{article}\n")
+	fmt.Fprintf(&b, "\\usepackage[papersize={%.2fbp,%.2fbp},margin=0pt]{geometry}\n", paperWidth, layout.height)
+	b.WriteString("\\usepackage[T1]{fontenc}\n")
+	b.WriteString("\\usepackage[utf8]{inputenc}\n")
+	b.WriteString("\\usepackage{graphicx}\n")
+	b.WriteString("\\usepackage{xcolor}\n")
+	b.WriteString("\\usepackage{eso-pic}\n")
+	b.WriteString("\\pagestyle{empty}\n")
+	b.WriteString("\\setlength{\\unitlength}{1bp}\n")
+	b.WriteString("\\begin{document}\n")
+	b.WriteString("\\AddToShipoutPictureBG*{%\n")
+
+	if background != "" {
+		fmt.Fprintf(&b, "\\put(0,0){\\includegraphics[width=\\paperwidth,height=\\paperheight]{%s}}%%\n", background)
+	}
+
+	fmt.Fprintf(&b, "\\put(%.2f,0){\\makebox(%.2f,%.2f){\\parbox{%.2fbp}{\\centering\\color[HTML]{%s}%%\n",
+		frontX, layout.width, layout.height, layout.width-2*coverMargin, layout.textColor)
+	fmt.Fprintf(&b, "{\\fontsize{32}{38}\\selectfont\\bfseries %s\\par}\n", escapeLatex(layout.title))
+	if layout.subtitle != "" {
+		fmt.Fprintf(&b, "\\vspace{1em}{\\Large %s\\par}\n", escapeLatex(layout.subtitle))
+	}
+	if layout.author != "" {
+		fmt.Fprintf(&b, "\\vspace{3em}{\\LARGE %s\\par}\n", escapeLatex(layout.author))
+	}
+	b.WriteString("}}}%\n")
+
+	if layout.back {
+		fmt.Fprintf(&b, "\\put(0,0){\\makebox(%.2f,%.2f){\\parbox{%.2fbp}{\\color[HTML]{%s}\\large %s}}}%%\n",
+			layout.width, layout.height, layout.width-2*coverMargin, layout.textColor, escapeLatex(layout.backText))
+	}
+
+	if layout.spine >= minSpineWidth {
+		fmt.Fprintf(&b, "\\put(%.2f,0){\\makebox(%.2f,%.2f){\\rotatebox{-90}{\\color[HTML]{%s}\\bfseries %s}}}%%\n",
+			spineX, layout.spine, layout.height, layout.textColor, escapeLatex(spineText(layout)))
+	}
+
+	b.WriteString("}\n")
+	b.WriteString("\\null\n")
+	b.WriteString("\\end{document}\n")
+
+	return b.String()
+}
+
+func spineText(layout coverLayout) string {
+	if layout.author == "" {
+		return layout.title
+	}
+
+	return layout.author + " - " + layout.title
+}
+
+func coverPng(pdfFile string, dpi int) error {
+	_, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		utils.PrintWarning("pdftoppm not found, skipping png cover " + pdfFile)
+		return nil
+	}
+
+	if dpi <= 0 {
+		dpi = defaultCoverDpi
+	}
+
+	prefix := strings.TrimSuffix(pdfFile, path.Ext(pdfFile))
+	out, err := exec.Command("pdftoppm",
+		"-png",
+		"-r", fmt.Sprint(dpi),
+		"-singlefile",
+		pdfFile,
+		prefix,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pdftoppm failed with error '%s'", strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zivlakmilos/author/data"
+)
+
+var (
+	reTodoComment   = regexp.MustCompile(`(?s)<!--\s*TODO:?\s*(.*?)\s*-->`)
+	reCommentMarker = regexp.MustCompile(`(?m)^\[comment\]:\s*(?:#|<>)\s*(?:\((.*)\)|"(.*)")[ \t]*$`)
+)
+
+func processReviewMarkers(content string, draft bool) string {
+	return mapOutsideFences(content, func(text string) string {
+		return replaceReviewMarkers(text, draft)
+	})
+}
+
+func replaceReviewMarkers(text string, draft bool) string {
+	text = reTodoComment.ReplaceAllStringFunc(text, func(match string) string {
+		m := reTodoComment.FindStringSubmatch(match)
+		return reviewNote(m[1], strings.Count(match, "\n"), draft)
+	})
+
+	return reCommentMarker.ReplaceAllStringFunc(text, func(match string) string {
+		m := reCommentMarker.FindStringSubmatch(match)
+		return reviewNote(m[1]+m[2], 0, draft)
+	})
+}
+
+func reviewNote(note string, lines int, draft bool) string {
+	padding := strings.Repeat("\n", lines)
+	if !draft {
+		return padding
+	}
+
+	note = strings.Join(strings.Fields(note), " ")
+	if note == "" {
+		note = "TODO"
+	}
+
+	return fmt.Sprintf("`\\todo{%s}`{=latex}", escapeLatex(note)) + padding
+}
+
+func pdfDraftHeader(project *data.Project) string {
+	stamp := []string{"DRAFT"}
+	if project.Version != "" {
+		stamp = append(stamp, project.Version)
+	}
+	if hash := gitShortHash(project.Dir); hash != "" {
+		stamp = append(stamp, hash)
+	}
+	stamp = append(stamp, time.Now().Format("2006-01-02"))
+
+	var b strings.Builder
+	b.WriteString("\\usepackage{draftwatermark}\n")
+	fmt.Fprintf(&b, "\\SetWatermarkText{%s}\n", escapeLatex(strings.Join(stamp, " ")))
+	b.WriteString("\\SetWatermarkScale{0.3}\n")
+	b.WriteString("\\SetWatermarkColor[gray]{0.9}\n")
+	b.WriteString("\\usepackage{lineno}\n")
+	b.WriteString("\\AtBeginDocument{\\linenumbers}\n")
+	b.WriteString("\\usepackage[textsize=scriptsize]{todonotes}\n")
+
+	return b.String()
+}
+
+func gitShortHash(dir string) string {
+	if dir == "" {
+		dir = "."
+	}
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
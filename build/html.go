@@ -22,9 +22,12 @@ THE SOFTWARE.
 package build
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zivlakmilos/author/data"
@@ -35,6 +38,14 @@ import (
 type processHtml struct {
 	body    *html.Node
 	section *html.Node
+
+	images     map[string]imageAsset
+	imageSizes string
+	reader     data.ProjectHtmlReader
+
+	project *data.Project
+	meta    documentMetadata
+	page    string
 }
 
 func buildHtml(project *data.Project) error {
@@ -66,6 +77,8 @@ func buildHtml(project *data.Project) error {
 		args = append(args, "--biblatex")
 	}
 
+	args = append(args, projectMetadataArgs(project)...)
+
 	err := os.MkdirAll(path.Join(project.OutputFolder, project.Html.OutputFolder, "assets"), os.ModePerm)
 	if err != nil {
 		return err
@@ -77,16 +90,51 @@ func buildHtml(project *data.Project) error {
 		return err
 	}
 
-	err = pandoc(project.Sources, args, timeout)
+	var images map[string]imageAsset
+	if project.Html.Images.Optimize {
+		images, err = optimizeHtmlImages(path.Join(project.OutputFolder, project.Html.OutputFolder), project)
+		if err != nil {
+			return err
+		}
+	}
+
+	srcs, cleanup, err := prepareSources(project.Sources, sourceOptions{
+		dir: project.OutputFolder,
+	})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	err = pandoc(project.Dir, srcs, args, timeout)
+	if err != nil {
+		return err
+	}
+
+	err = postProcessHtml(project, images)
 	if err != nil {
 		return err
 	}
 
-	err = postProcessHtml(project)
+	err = writeSitemap(project)
 	if err != nil {
 		return err
 	}
 
+	if project.Html.LinkCheck.Enabled {
+		err = checkLinksRun(project)
+		if err != nil {
+			return err
+		}
+	}
+
+	if project.Html.Production {
+		err = buildHtmlProduction(path.Join(project.OutputFolder, project.Html.OutputFolder))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -119,8 +167,9 @@ func copyHtmlAssets(dst string, html *data.ProjectHtml, assets []string) error {
 	return nil
 }
 
-func postProcessHtml(project *data.Project) error {
-	filePath := path.Join(project.OutputFolder, project.Html.OutputFolder, "index.html")
+func postProcessHtml(project *data.Project, images map[string]imageAsset) error {
+	page := "index.html"
+	filePath := path.Join(project.OutputFolder, project.Html.OutputFolder, page)
 	f, err := os.Open(filePath)
 	if err != nil {
 		return err
@@ -132,9 +181,39 @@ func postProcessHtml(project *data.Project) error {
 		return err
 	}
 
-	p := processHtml{}
+	meta, err := loadProjectMetadata(project)
+	if err != nil {
+		return err
+	}
+
+	p := processHtml{
+		images:     images,
+		imageSizes: project.Html.Images.Sizes,
+		reader:     project.Html.Reader,
+		project:    project,
+		meta:       meta,
+		page:       page,
+	}
+	if p.imageSizes == "" {
+		p.imageSizes = defaultImageSizes
+	}
 	p.postProcessHtmlNode(node)
 
+	if project.Html.Accessibility.Check {
+		issues, err := checkHtmlAccessibility(project, node)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			utils.PrintWarning(issue.String())
+		}
+
+		if project.Html.Accessibility.Fail && len(issues) > 0 {
+			return fmt.Errorf("found %d accessibility issues", len(issues))
+		}
+	}
+
 	f, err = os.Create(filePath)
 	if err != nil {
 		return err
@@ -150,6 +229,18 @@ func postProcessHtml(project *data.Project) error {
 
 func (p *processHtml) postProcessHtmlNode(node *html.Node) {
 	if node.Type == html.ElementNode {
+		switch node.Data {
+		case "html":
+			p.postProcessHtmlRoot(node)
+			p.postProcessHtmlLang(node)
+		case "head":
+			p.postProcessHtmlHead(node)
+		case "body":
+			p.postProcessHtmlSkipLink(node)
+		}
+
+		p.postProcessHtmlLandmark(node)
+
 		id := utils.GetHtmlId(node)
 
 		if id == "author-toc" {
@@ -178,6 +269,27 @@ func (p *processHtml) postProcessHtmlNode(node *html.Node) {
 	}
 }
 
+func (p *processHtml) postProcessHtmlRoot(node *html.Node) {
+	theme := p.reader.Theme
+	if theme == "" {
+		theme = "light"
+	}
+
+	fontSize := p.reader.FontSize
+	if fontSize <= 0 {
+		fontSize = 16
+	}
+
+	textAlign := p.reader.TextAlign
+	if textAlign == "" {
+		textAlign = "justify"
+	}
+
+	utils.SetHtmlAttribute(node, "data-theme", theme)
+	utils.SetHtmlAttribute(node, "data-font-size", strconv.Itoa(fontSize))
+	utils.SetHtmlAttribute(node, "data-text-align", textAlign)
+}
+
 func (p *processHtml) postProcessHtmlToc(node *html.Node) {
 	if node.Type == html.ElementNode {
 		switch node.Data {
@@ -299,13 +411,40 @@ func (p *processHtml) postProcessHtmlTag(node *html.Node) {
 }
 
 func (p *processHtml) postProcessHtmlImg(node *html.Node) {
-	idx := utils.FindOrAppendAtribute(node, "style")
-	node.Attr[idx].Val = "max-width: 100%;"
+	utils.AddHtmlClass(node, "author-img")
+
+	if p.images == nil {
+		return
+	}
+
+	src, _ := utils.GetHtmlAttribute(node, "src")
+	asset, ok := p.images[path.Clean(src)]
+	if !ok {
+		return
+	}
+
+	utils.SetHtmlAttribute(node, "loading", "lazy")
+
+	_, hasWidth := utils.GetHtmlAttribute(node, "width")
+	_, hasHeight := utils.GetHtmlAttribute(node, "height")
+	if !hasWidth && !hasHeight {
+		utils.SetHtmlAttribute(node, "width", strconv.Itoa(asset.width))
+		utils.SetHtmlAttribute(node, "height", strconv.Itoa(asset.height))
+	}
+
+	if len(asset.variants) > 1 {
+		var srcset []string
+		for _, variant := range asset.variants {
+			srcset = append(srcset, fmt.Sprintf("%s %dw", variant.src, variant.width))
+		}
+
+		utils.SetHtmlAttribute(node, "srcset", strings.Join(srcset, ", "))
+		utils.SetHtmlAttribute(node, "sizes", p.imageSizes)
+	}
 }
 
 func (p *processHtml) postProcessHtmlParagraph(node *html.Node) {
-	idx := utils.FindOrAppendAtribute(node, "style")
-	node.Attr[idx].Val = "text-indent: 20px; text-align: justify;"
+	utils.AddHtmlClass(node, "author-paragraph")
 }
 
 func (p *processHtml) postProcessHtmlTable(node *html.Node) {
@@ -0,0 +1,312 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	defaultImageQuality   = 85
+	defaultImageSizes     = "100vw"
+	imageVariantsFileName = ".author-image-variants"
+)
+
+var defaultImageWidths = []int{480, 960, 1440}
+
+type imageVariant struct {
+	src   string
+	width int
+}
+
+type imageAsset struct {
+	width    int
+	height   int
+	variants []imageVariant
+}
+
+func optimizeHtmlImages(dst string, project *data.Project) (map[string]imageAsset, error) {
+	cfg := &project.Html.Images
+
+	quality := cfg.Quality
+	if quality <= 0 || quality > 100 {
+		quality = defaultImageQuality
+	}
+
+	widths := cfg.Widths
+	if len(widths) == 0 {
+		widths = defaultImageWidths
+	}
+
+	cacheDir := cfg.CacheFolder
+	if cacheDir == "" {
+		cacheDir = path.Join(project.OutputFolder, ".cache", "images")
+	}
+
+	err := os.MkdirAll(cacheDir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	variantsFile := path.Join(dst, imageVariantsFileName)
+	generated := readImageVariants(variantsFile)
+
+	var srcs []string
+	err = fs.WalkDir(os.DirFS(dst), "assets", func(pth string, dir fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dir.IsDir() || !isOptimizableImage(pth) || slices.Contains(generated, pth) {
+			return nil
+		}
+
+		srcs = append(srcs, pth)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	images := map[string]imageAsset{}
+	generated = nil
+	for _, src := range srcs {
+		asset, err := optimizeHtmlImage(dst, src, cacheDir, quality, widths)
+		if err != nil {
+			return nil, fmt.Errorf("error optimizing image '%s': %v", src, err)
+		}
+
+		for _, v := range asset.variants {
+			if v.src != src {
+				generated = append(generated, v.src)
+			}
+		}
+
+		images[src] = asset
+	}
+
+	err = os.WriteFile(variantsFile, []byte(strings.Join(generated, "\n")), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// readImageVariants returns variants generated by previous build, so they are
+// not optimized again as source images.
+func readImageVariants(file string) []string {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	return strings.Fields(string(content))
+}
+
+func optimizeHtmlImage(dst, src, cacheDir string, quality int, widths []int) (imageAsset, error) {
+	asset := imageAsset{}
+	filePath := path.Join(dst, src)
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return asset, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return asset, err
+	}
+
+	asset.width = cfg.Width
+	asset.height = cfg.Height
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	ext := strings.ToLower(path.Ext(src))
+
+	orientation := 1
+	if ext != ".png" {
+		orientation = jpegOrientation(content)
+	}
+	if orientation >= 5 {
+		asset.width, asset.height = asset.height, asset.width
+	}
+
+	var img image.Image
+	decode := func() (image.Image, error) {
+		if img != nil {
+			return img, nil
+		}
+
+		var err error
+		img, _, err = image.Decode(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+
+		img = orientImage(img, orientation)
+		return img, nil
+	}
+
+	// png is lossless, quality is used only for jpeg
+	cacheName := fmt.Sprintf("%s-q%d", hash, quality)
+	if ext == ".png" {
+		cacheName = hash
+	}
+	if orientation != 1 {
+		cacheName += fmt.Sprintf("-o%d", orientation)
+	}
+
+	cacheFile := path.Join(cacheDir, cacheName+ext)
+	encoded, err := cachedImage(cacheFile, func() ([]byte, error) {
+		img, err := decode()
+		if err != nil {
+			return nil, err
+		}
+
+		return encodeImage(img, ext, quality)
+	})
+	if err != nil {
+		return asset, err
+	}
+
+	// encoded image has no exif, so rotated image is always written
+	if len(encoded) < len(content) || orientation != 1 {
+		err = os.WriteFile(filePath, encoded, 0644)
+		if err != nil {
+			return asset, err
+		}
+	}
+
+	for _, width := range widths {
+		if width <= 0 || width >= asset.width {
+			continue
+		}
+
+		height := asset.height * width / asset.width
+		cacheFile := path.Join(cacheDir, fmt.Sprintf("%s-%dw%s", cacheName, width, ext))
+		encoded, err := cachedImage(cacheFile, func() ([]byte, error) {
+			img, err := decode()
+			if err != nil {
+				return nil, err
+			}
+
+			return encodeImage(resizeImage(img, width, height), ext, quality)
+		})
+		if err != nil {
+			return asset, err
+		}
+
+		variant := imageVariantPath(src, width)
+		err = os.WriteFile(path.Join(dst, variant), encoded, 0644)
+		if err != nil {
+			return asset, err
+		}
+
+		asset.variants = append(asset.variants, imageVariant{
+			src:   variant,
+			width: width,
+		})
+	}
+
+	asset.variants = append(asset.variants, imageVariant{
+		src:   src,
+		width: asset.width,
+	})
+
+	return asset, nil
+}
+
+func cachedImage(cacheFile string, encode func() ([]byte, error)) ([]byte, error) {
+	content, err := os.ReadFile(cacheFile)
+	if err == nil {
+		return content, nil
+	}
+
+	content, err = encode()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(cacheFile, content, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func resizeImage(img image.Image, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+
+	return dst
+}
+
+func encodeImage(img image.Image, ext string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch ext {
+	case ".png":
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err := encoder.Encode(&buf, img)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func imageVariantPath(src string, width int) string {
+	ext := path.Ext(src)
+	return fmt.Sprintf("%s-%dw%s", strings.TrimSuffix(src, ext), width, ext)
+}
+
+func isOptimizableImage(pth string) bool {
+	switch strings.ToLower(path.Ext(pth)) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+
+	return false
+}
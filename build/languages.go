@@ -0,0 +1,244 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+	"golang.org/x/net/html"
+)
+
+type languagePage struct {
+	key      string
+	lang     string
+	label    string
+	file     string
+	sections []string
+}
+
+func buildLanguages(project *data.Project) error {
+	var pages []languagePage
+
+	for _, key := range project.LanguageKeys() {
+		lp := project.LanguageProject(key)
+
+		utils.PrintInfo(fmt.Sprintf("building language %s", key))
+		err := buildTargets(lp)
+		if err != nil {
+			return fmt.Errorf("language %s: %v", key, err)
+		}
+
+		pages = append(pages, languagePage{
+			key:   key,
+			lang:  lp.Lang,
+			label: project.Languages[key].DisplayLabel(key),
+			file:  path.Join(lp.OutputFolder, lp.Html.OutputFolder, "index.html"),
+		})
+	}
+
+	if !slices.Contains(project.Targets, "html") || len(pages) < 2 {
+		return nil
+	}
+
+	for i := range pages {
+		node, err := readHtmlFile(pages[i].file)
+		if err != nil {
+			return err
+		}
+
+		pages[i].sections = htmlSectionIds(node)
+	}
+
+	for _, page := range pages {
+		err := addLanguageSwitcher(page, pages)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addLanguageSwitcher(page languagePage, pages []languagePage) error {
+	node, err := readHtmlFile(page.file)
+	if err != nil {
+		return err
+	}
+
+	hrefs := map[string]string{}
+	for _, other := range pages {
+		rel, err := filepath.Rel(path.Dir(page.file), other.file)
+		if err != nil {
+			return err
+		}
+		hrefs[other.key] = filepath.ToSlash(rel)
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "head":
+				for _, other := range pages {
+					n.AppendChild(newHtmlElement("link", "",
+						"rel", "alternate",
+						"hreflang", other.lang,
+						"href", hrefs[other.key],
+					))
+				}
+			case "body":
+				nav := newHtmlElement("nav", "",
+					"class", "author-language-switcher",
+					"aria-label", "Language",
+				)
+				for _, other := range pages {
+					a := languageLink(other, hrefs[other.key])
+					if other.key == page.key {
+						utils.SetHtmlAttribute(a, "aria-current", "page")
+					}
+					nav.AppendChild(a)
+				}
+
+				before := n.FirstChild
+				if before != nil {
+					if class, _ := utils.GetHtmlAttribute(before, "class"); class == "author-skip-link" {
+						before = before.NextSibling
+					}
+				}
+				n.InsertBefore(nav, before)
+			case "section":
+				idx := slices.Index(page.sections, utils.GetHtmlId(n))
+				if idx < 0 {
+					break
+				}
+
+				links := newHtmlElement("p", "", "class", "author-language-links")
+				for _, other := range pages {
+					if other.key == page.key || idx >= len(other.sections) {
+						continue
+					}
+					links.AppendChild(languageLink(other, hrefs[other.key]+"#"+other.sections[idx]))
+				}
+
+				if links.FirstChild != nil {
+					insertAfterHeading(n, links)
+				}
+				return
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return writeHtmlFile(page.file, node)
+}
+
+func languageLink(page languagePage, href string) *html.Node {
+	return newHtmlElement("a", page.label,
+		"href", href,
+		"hreflang", page.lang,
+		"lang", page.lang,
+	)
+}
+
+func htmlSectionIds(node *html.Node) []string {
+	var ids []string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if utils.GetHtmlId(n) == "author-body" {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "section" && utils.GetHtmlId(c) != "" {
+					ids = append(ids, utils.GetHtmlId(c))
+				}
+			}
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return ids
+}
+
+func insertAfterHeading(section, node *html.Node) {
+	for c := section.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "h1" {
+			section.InsertBefore(node, c.NextSibling)
+			return
+		}
+	}
+
+	section.InsertBefore(node, section.FirstChild)
+}
+
+func newHtmlElement(tag, text string, attrs ...string) *html.Node {
+	node := &html.Node{
+		Type: html.ElementNode,
+		Data: tag,
+	}
+
+	for i := 0; i+1 < len(attrs); i += 2 {
+		node.Attr = append(node.Attr, html.Attribute{Key: attrs[i], Val: attrs[i+1]})
+	}
+
+	if text != "" {
+		node.AppendChild(&html.Node{
+			Type: html.TextNode,
+			Data: text,
+		})
+	}
+
+	return node
+}
+
+func readHtmlFile(filePath string) (*html.Node, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return html.Parse(f)
+}
+
+func writeHtmlFile(filePath string, node *html.Node) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return html.Render(f, node)
+}
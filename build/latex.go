@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+)
+
+func runLatex(dir, name, source string) error {
+	err := os.WriteFile(path.Join(dir, name+".tex"), []byte(source), 0644)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(defaultPdfEngine,
+		"-interaction=nonstopmode",
+		"-halt-on-error",
+		name+".tex",
+	)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"SOURCE_DATE_EPOCH=0",
+		"FORCE_SOURCE_DATE=1",
+	)
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("%s failed with error '%v'", defaultPdfEngine, err)
+	}
+
+	ch := make(chan error)
+	go func(cmd *exec.Cmd) {
+		defer close(ch)
+		ch <- cmd.Wait()
+	}(cmd)
+
+	select {
+	case err = <-ch:
+		if err != nil {
+			return latexError(path.Join(dir, name+".log"), err)
+		}
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		return fmt.Errorf("%s execute timeout", defaultPdfEngine)
+	}
+
+	return nil
+}
+
+func latexError(logFile string, err error) error {
+	diagnostics, _ := parseLatexLog(logFile)
+	for _, d := range diagnostics {
+		if d.severity == "error" {
+			return fmt.Errorf("%s failed with error '%s'", defaultPdfEngine, d.message)
+		}
+	}
+
+	return fmt.Errorf("%s failed with error '%v'", defaultPdfEngine, err)
+}
+
+func latexPreamble() string {
+	return "\\pdfinfoomitdate=1\n" +
+		"\\pdftrailerid{}\n" +
+		"\\pdfsuppressptexinfo=-1\n"
+}
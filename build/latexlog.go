@@ -0,0 +1,415 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+const defaultPdfEngine = "pdflatex"
+
+var (
+	reLatexFileLineError = regexp.MustCompile(`^(.*\.tex):(\d+): (.*)$`)
+	reLatexErrorLine     = regexp.MustCompile(`^l\.(\d+)`)
+	reLatexBox           = regexp.MustCompile(`^((?:Overfull|Underfull) \\[hv]box .*?) (?:in paragraph|in alignment|detected) at lines? (\d+)`)
+	reLatexInputLine     = regexp.MustCompile(`^(?:LaTeX|Package \w+) Warning: (.*?) on input line (\d+)\.`)
+	reLatexCommand       = regexp.MustCompile(`\\[a-zA-Z]+`)
+)
+
+type latexDiagnostic struct {
+	severity string
+	line     int
+	message  string
+}
+
+type sourceLine struct {
+	file string
+	line int
+	text string
+}
+
+// buildPdfIntermediate writes latex source next to pdf output and compiles
+// it in temporary folder, so pdf output is not touched unless final is set.
+// Final build runs as many passes as pandoc would and copies pdf to output.
+// Latex log is copied next to latex source.
+func buildPdfIntermediate(project *data.Project, srcs, args []string, final bool) (string, error) {
+	outDir := path.Join(project.OutputFolder, project.Pdf.OutputFolder)
+	name := strings.TrimSuffix(project.Pdf.OutputFileName, path.Ext(project.Pdf.OutputFileName))
+	texFile := path.Join(outDir, name+".tex")
+
+	texArgs := replacePandocArg(args, "-t", "latex")
+	texArgs = replacePandocArg(texArgs, "-o", texFile)
+
+	err := pandoc(project.Dir, srcs, texArgs, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "author-latex-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	var env []string
+	if project.Pdf.Split != "" {
+		env = append(env, "openout_any=a")
+	}
+
+	engine := pdfEngine(args)
+	latex := func() error {
+		return runCommand(project.Dir, engine, env,
+			"-interaction=nonstopmode",
+			"-file-line-error",
+			"-output-directory", dir,
+			texFile,
+		)
+	}
+
+	logFile := path.Join(dir, name+".log")
+	err = latex()
+	if final && err == nil {
+		if project.Biblatex {
+			err = runCommand(project.Dir, "biber", nil, "--input-directory", dir, "--output-directory", dir, name)
+		}
+
+		for pass := 0; err == nil && pass < 2; pass++ {
+			err = latex()
+			if !latexNeedsRerun(logFile) {
+				break
+			}
+		}
+	}
+
+	if cerr := utils.CopyFile(logFile, strings.TrimSuffix(texFile, ".tex")+".log"); cerr != nil {
+		return "", cerr
+	}
+
+	if err != nil {
+		if final {
+			return texFile, err
+		}
+		return texFile, nil
+	}
+
+	if final {
+		err = utils.CopyFile(path.Join(dir, name+".pdf"), path.Join(outDir, project.Pdf.OutputFileName))
+		if err != nil {
+			return texFile, err
+		}
+	}
+
+	return texFile, nil
+}
+
+func latexNeedsRerun(logFile string) bool {
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(content), "Rerun to get") ||
+		strings.Contains(string(content), "Label(s) may have changed")
+}
+
+func runCommand(dir, name string, env []string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		return fmt.Errorf("%s failed with error '%v'", name, err)
+	}
+
+	ch := make(chan error)
+	go func(cmd *exec.Cmd) {
+		defer close(ch)
+		ch <- cmd.Wait()
+	}(cmd)
+
+	select {
+	case err = <-ch:
+		if err != nil {
+			return fmt.Errorf("%s failed with error '%v'", name, err)
+		}
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		return fmt.Errorf("%s execute timeout", name)
+	}
+
+	return nil
+}
+
+func reportLatexLog(project *data.Project, texFile string) (int, error) {
+	logFile := strings.TrimSuffix(texFile, ".tex") + ".log"
+
+	diagnostics, err := parseLatexLog(logFile)
+	if err != nil {
+		return 0, err
+	}
+
+	texLines, err := readLines(texFile)
+	if err != nil {
+		return 0, err
+	}
+
+	srcLines, err := readSourceLines(project.Sources)
+	if err != nil {
+		return 0, err
+	}
+
+	errors := 0
+	for _, d := range diagnostics {
+		if d.severity == "error" {
+			errors++
+		}
+
+		location := texFile
+		if d.line > 0 {
+			location = fmt.Sprintf("%s:%d", texFile, d.line)
+		}
+		if src := mapTexLine(texLines, d.line, srcLines); src != nil {
+			location = fmt.Sprintf("%s:%d", src.file, src.line)
+		}
+
+		fmt.Printf("%s: %s: %s\n", location, d.severity, d.message)
+	}
+
+	return errors, nil
+}
+
+func parseLatexLog(logFile string) ([]latexDiagnostic, error) {
+	lines, err := readLines(logFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []latexDiagnostic
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := reLatexFileLineError.FindStringSubmatch(line); m != nil {
+			num, _ := strconv.Atoi(m[2])
+			diagnostics = append(diagnostics, latexDiagnostic{
+				severity: "error",
+				line:     num,
+				message:  m[3],
+			})
+			continue
+		}
+
+		if strings.HasPrefix(line, "! ") {
+			d := latexDiagnostic{
+				severity: "error",
+				message:  strings.TrimPrefix(line, "! "),
+			}
+
+			for j := i + 1; j < len(lines) && j < i+20; j++ {
+				if m := reLatexErrorLine.FindStringSubmatch(lines[j]); m != nil {
+					d.line, _ = strconv.Atoi(m[1])
+					break
+				}
+			}
+
+			diagnostics = append(diagnostics, d)
+			continue
+		}
+
+		if m := reLatexBox.FindStringSubmatch(line); m != nil {
+			num, _ := strconv.Atoi(m[2])
+			diagnostics = append(diagnostics, latexDiagnostic{
+				severity: "warning",
+				line:     num,
+				message:  m[1],
+			})
+			continue
+		}
+
+		if strings.Contains(line, "Warning:") {
+			text := line
+			for j := i + 1; j < len(lines) && j < i+5 && !strings.HasSuffix(text, "."); j++ {
+				text += " " + strings.TrimSpace(lines[j])
+			}
+
+			if m := reLatexInputLine.FindStringSubmatch(text); m != nil {
+				num, _ := strconv.Atoi(m[2])
+				diagnostics = append(diagnostics, latexDiagnostic{
+					severity: "warning",
+					line:     num,
+					message:  m[1],
+				})
+			}
+		}
+	}
+
+	return diagnostics, nil
+}
+
+func mapTexLine(texLines []string, line int, srcLines []sourceLine) *sourceLine {
+	if line <= 0 || line > len(texLines) {
+		return nil
+	}
+
+	words := latexWords(texLines[line-1])
+	if len(words) == 0 && line < len(texLines) {
+		words = latexWords(texLines[line])
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	var best *sourceLine
+	bestScore := 0
+	for i := range srcLines {
+		score := 0
+		text := strings.ToLower(srcLines[i].text)
+		for _, word := range words {
+			if strings.Contains(text, word) {
+				score++
+			}
+		}
+
+		if score > bestScore {
+			best = &srcLines[i]
+			bestScore = score
+		}
+	}
+
+	if bestScore*2 < len(words) && bestScore < 3 {
+		return nil
+	}
+
+	return best
+}
+
+func latexWords(line string) []string {
+	line = reLatexCommand.ReplaceAllString(line, " ")
+
+	var words []string
+	for _, word := range strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 4 {
+			words = append(words, strings.ToLower(word))
+		}
+	}
+
+	return words
+}
+
+func readSourceLines(srcs []string) ([]sourceLine, error) {
+	var lines []sourceLine
+
+	for _, src := range srcs {
+		content, err := readLines(src)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, text := range content {
+			lines = append(lines, sourceLine{
+				file: src,
+				line: i + 1,
+				text: text,
+			})
+		}
+	}
+
+	return lines, nil
+}
+
+func readLines(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+func replacePandocArg(args []string, flag, val string) []string {
+	res := make([]string, len(args))
+	copy(res, args)
+
+	for i := 0; i+1 < len(res); i++ {
+		if res[i] == flag {
+			res[i+1] = val
+		}
+	}
+
+	return res
+}
+
+func pdfEngine(args []string) string {
+	for i, arg := range args {
+		if val, ok := strings.CutPrefix(arg, "--pdf-engine="); ok {
+			return val
+		}
+
+		if arg == "--pdf-engine" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+
+	return defaultPdfEngine
+}
+
+func keepPdfIntermediate(project *data.Project, srcs, args []string, failed bool) error {
+	texFile, buildErr := buildPdfIntermediate(project, srcs, args, !failed)
+	if texFile == "" {
+		return buildErr
+	}
+
+	errors, err := reportLatexLog(project, texFile)
+	if err != nil {
+		return err
+	}
+
+	if failed || errors > 0 || buildErr != nil {
+		utils.PrintInfo(fmt.Sprintf("intermediate files kept in %s", path.Dir(texFile)))
+	}
+
+	return buildErr
+}
@@ -0,0 +1,238 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+	"golang.org/x/net/html"
+)
+
+type linkReference struct {
+	attr    string
+	val     string
+	heading headingRef
+}
+
+type linkChecker struct {
+	ids        map[string]bool
+	references []linkReference
+	headings   headingCounter
+	heading    headingRef
+}
+
+func CheckLinks() {
+	project, err := data.OpenProject()
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	project, err = expandProject(project)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	err = checkLinksRun(project)
+	if err != nil {
+		utils.ExitWithError(err)
+		return
+	}
+
+	utils.PrintSuccess("no broken links found")
+}
+
+func checkLinksRun(project *data.Project) error {
+	issues, err := checkHtmlLinks(project)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		utils.PrintError(fmt.Errorf("%s", issue))
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d broken links", len(issues))
+	}
+
+	return nil
+}
+
+func checkHtmlLinks(project *data.Project) ([]sourceIssue, error) {
+	dir := path.Join(project.OutputFolder, project.Html.OutputFolder)
+	filePath := path.Join(dir, "index.html")
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := html.Parse(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	headings, err := scanSourceHeadings(project.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	c := linkChecker{
+		ids:      map[string]bool{},
+		headings: headingCounter{},
+	}
+	c.walk(node)
+
+	var issues []sourceIssue
+	for _, ref := range c.references {
+		message := c.checkReference(dir, &project.Html.LinkCheck, ref)
+		if message == "" {
+			continue
+		}
+
+		issues = append(issues, newSourceIssue(headings, filePath, ref.heading, message))
+	}
+
+	return issues, nil
+}
+
+func (c *linkChecker) walk(node *html.Node) {
+	if node.Type == html.ElementNode {
+		if id := utils.GetHtmlId(node); id != "" {
+			c.ids[id] = true
+		}
+
+		switch node.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			c.heading = c.headings.next(utils.GetHtmlText(node))
+		case "a":
+			if name, ok := utils.GetHtmlAttribute(node, "name"); ok {
+				c.ids[name] = true
+			}
+			c.addReference(node, "href")
+		case "link":
+			c.addReference(node, "href")
+		case "img", "script", "source":
+			c.addReference(node, "src")
+		}
+	}
+
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		c.walk(n)
+	}
+}
+
+func (c *linkChecker) addReference(node *html.Node, attr string) {
+	val, ok := utils.GetHtmlAttribute(node, attr)
+	if !ok {
+		return
+	}
+
+	c.references = append(c.references, linkReference{
+		attr:    attr,
+		val:     val,
+		heading: c.heading,
+	})
+}
+
+func (c *linkChecker) checkReference(dir string, cfg *data.ProjectLinkCheck, ref linkReference) string {
+	val := strings.TrimSpace(ref.val)
+	if val == "" {
+		return fmt.Sprintf("empty %s attribute", ref.attr)
+	}
+
+	if strings.HasPrefix(val, "#") {
+		anchor, err := url.PathUnescape(val[1:])
+		if err != nil {
+			anchor = val[1:]
+		}
+
+		if anchor != "" && !c.ids[anchor] {
+			return fmt.Sprintf("missing anchor '%s'", val)
+		}
+
+		return ""
+	}
+
+	u, err := url.Parse(val)
+	if err != nil {
+		return fmt.Sprintf("invalid link '%s'", val)
+	}
+
+	switch u.Scheme {
+	case "mailto", "tel", "javascript", "data":
+		return ""
+	case "":
+		if u.Host != "" {
+			return checkExternalLink(cfg, u)
+		}
+	default:
+		return checkExternalLink(cfg, u)
+	}
+
+	if u.Path == "" {
+		return ""
+	}
+
+	_, err = os.Stat(path.Join(dir, u.Path))
+	if err != nil {
+		return fmt.Sprintf("missing file '%s'", u.Path)
+	}
+
+	return ""
+}
+
+func checkExternalLink(cfg *data.ProjectLinkCheck, u *url.URL) string {
+	for _, pattern := range cfg.Deny {
+		if matchLinkPattern(pattern, u) {
+			return fmt.Sprintf("external link '%s' is denied", u)
+		}
+	}
+
+	if len(cfg.Allow) == 0 {
+		return ""
+	}
+
+	for _, pattern := range cfg.Allow {
+		if matchLinkPattern(pattern, u) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("external link '%s' is not allowed", u)
+}
+
+func matchLinkPattern(pattern string, u *url.URL) bool {
+	if strings.Contains(pattern, "://") {
+		return strings.HasPrefix(u.String(), pattern)
+	}
+
+	matched, _ := path.Match(pattern, u.Hostname())
+	return matched
+}
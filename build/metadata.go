@@ -0,0 +1,170 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zivlakmilos/author/data"
+	"gopkg.in/yaml.v3"
+)
+
+type documentMetadata map[string]any
+
+func loadProjectMetadata(project *data.Project) (documentMetadata, error) {
+	meta, err := loadDocumentMetadata(project.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, val := range project.Metadata {
+		meta[key] = val
+	}
+
+	if project.Lang != "" {
+		meta["lang"] = project.Lang
+	}
+
+	return meta, nil
+}
+
+func projectMetadataArgs(project *data.Project) []string {
+	var args []string
+
+	if project.Lang != "" {
+		args = append(args, "-M", "lang="+project.Lang)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(project.Metadata)) {
+		args = append(args, "-M", key+"="+project.Metadata[key])
+	}
+
+	return args
+}
+
+func loadDocumentMetadata(srcs []string) (documentMetadata, error) {
+	meta := documentMetadata{}
+
+	for _, src := range srcs {
+		block, err := readFrontMatter(src)
+		if err != nil {
+			return nil, err
+		}
+
+		if block == "" {
+			continue
+		}
+
+		var m map[string]any
+		err = yaml.Unmarshal([]byte(block), &m)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid front matter: %v", src, err)
+		}
+
+		for key, val := range m {
+			meta[key] = val
+		}
+	}
+
+	return meta, nil
+}
+
+func readFrontMatter(src string) (string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return "", scanner.Err()
+	}
+
+	var lines []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "---" || line == "..." {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+
+	return "", scanner.Err()
+}
+
+func (m documentMetadata) String(key string) string {
+	return metadataString(m[key])
+}
+
+func (m documentMetadata) Strings(key string) []string {
+	switch v := m[key].(type) {
+	case []any:
+		var items []string
+		for _, item := range v {
+			if s := metadataString(item); s != "" {
+				items = append(items, s)
+			}
+		}
+		return items
+	default:
+		if s := metadataString(v); s != "" {
+			return []string{s}
+		}
+	}
+
+	return nil
+}
+
+func (m documentMetadata) Bool(key string) bool {
+	val, _ := m[key].(bool)
+	return val
+}
+
+func metadataString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case time.Time:
+		return v.Format("2006-01-02")
+	case []any:
+		var items []string
+		for _, item := range v {
+			if s := metadataString(item); s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ", ")
+	case map[string]any:
+		return metadataString(v["name"])
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -23,12 +23,17 @@ package build
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"time"
 )
 
-func pandoc(srcs, args []string, timeout time.Duration) error {
+func pandoc(dir string, srcs, args []string, timeout time.Duration, env ...string) error {
 	cmd := exec.Command("pandoc", append(srcs, args...)...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	err := cmd.Start()
 	if err != nil {
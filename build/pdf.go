@@ -22,12 +22,16 @@ THE SOFTWARE.
 package build
 
 import (
+	"fmt"
 	"os"
 	"path"
 
 	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
 )
 
+const defaultPdfLangArg = "-V lang=rs-SR"
+
 func buildPdf(project *data.Project) error {
 	format := project.Format
 	if format == "markdown" {
@@ -41,14 +45,23 @@ func buildPdf(project *data.Project) error {
 		"-s",
 		"-o", path.Join(project.OutputFolder, project.Pdf.OutputFolder, project.Pdf.OutputFileName),
 		"--listings",
-		"-V lang=rs-SR",
 		//"--pdf-engine", "xelatex",
 	}
 
+	if project.Lang != "" {
+		args = append(args, "-V", "lang="+project.Lang)
+	} else {
+		args = append(args, defaultPdfLangArg)
+	}
+
 	if len(project.Pdf.Args) > 0 {
 		args = append(args, project.Pdf.Args...)
 	}
 
+	if project.Pdf.TopLevelDivision != "" {
+		args = append(args, "--top-level-division="+project.Pdf.TopLevelDivision)
+	}
+
 	if project.TableOfContent {
 		args = append(args, "--toc")
 	}
@@ -65,15 +78,154 @@ func buildPdf(project *data.Project) error {
 		args = append(args, "--biblatex")
 	}
 
-	err := os.MkdirAll(path.Join(project.OutputFolder, project.Pdf.OutputFolder), os.ModePerm)
+	args = append(args, projectMetadataArgs(project)...)
+
+	meta, err := loadProjectMetadata(project)
 	if err != nil {
 		return err
 	}
 
-	err = pandoc(project.Sources, args, timeout)
+	pdfMeta := newPdfMetadata(project, meta)
+	args = append(args, pdfMeta.args()...)
+
+	header, err := pdfMetadataHeader(project)
 	if err != nil {
 		return err
 	}
 
+	headers := []string{header}
+
+	bleedHeader, err := pdfBleedHeader(project)
+	if err != nil {
+		return err
+	}
+	if bleedHeader != "" {
+		headers = append(headers, bleedHeader)
+	}
+
+	if project.Pdf.Draft {
+		headers = append(headers, pdfDraftHeader(project))
+	}
+
+	var env []string
+	switch project.Pdf.Split {
+	case "":
+	case "file", "heading":
+		header, err := pdfSplitHeader(project)
+		if err != nil {
+			return err
+		}
+		headers = append(headers, header)
+		env = append(env, "openout_any=a")
+	default:
+		return fmt.Errorf("unsupported pdf split '%s', use file or heading", project.Pdf.Split)
+	}
+
+	headerFile, err := writePdfHeader(headers)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(headerFile)
+
+	args = append(args, "--include-in-header", headerFile)
+
+	err = os.MkdirAll(path.Join(project.OutputFolder, project.Pdf.OutputFolder), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	srcs, cleanup, err := prepareSources(project.Sources, sourceOptions{
+		draft:  project.Pdf.Draft,
+		split:  project.Pdf.Split,
+		rebase: project.Format == "markdown",
+		dir:    project.OutputFolder,
+	})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if project.Pdf.KeepIntermediate {
+		err = keepPdfIntermediate(project, srcs, args, false)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = pandoc(project.Dir, srcs, args, timeout, env...)
+		if err != nil {
+			ierr := keepPdfIntermediate(project, srcs, args, true)
+			if ierr != nil {
+				utils.PrintError(ierr)
+			}
+
+			return err
+		}
+	}
+
+	if project.Pdf.Split != "" {
+		err = buildPdfSplit(project)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordBookInfo(project)
+	if err != nil {
+		return err
+	}
+
+	before := project.Pdf.Merge.Before
+	if project.Cover.Enabled {
+		err = buildCover(project)
+		if err != nil {
+			return err
+		}
+
+		if project.Cover.Prepend {
+			before = append([]string{path.Join(coverOutputFolder(project), "front.pdf")}, before...)
+		}
+	}
+
+	if len(before) > 0 || len(project.Pdf.Merge.After) > 0 {
+		err = buildPdfMerge(project, before, project.Pdf.Merge.After)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkPdfMetadata(path.Join(project.OutputFolder, project.Pdf.OutputFolder, project.Pdf.OutputFileName), pdfMeta)
+	if err != nil {
+		if project.Pdf.PdfA != "" {
+			return err
+		}
+
+		utils.PrintWarning(err.Error())
+	}
+
+	if project.Pdf.Print.Enabled {
+		err = buildPdfPrint(project)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func writePdfHeader(headers []string) (string, error) {
+	f, err := os.CreateTemp("", "author-header-*.tex")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	for _, header := range headers {
+		_, err = f.WriteString(header)
+		if err != nil {
+			os.Remove(f.Name())
+			return "", err
+		}
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,327 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/zivlakmilos/author/data"
+)
+
+var rePdfStream = regexp.MustCompile(`(?s)stream\r?\n(.*?)endstream`)
+
+type pdfMetadata struct {
+	title    string
+	author   string
+	subject  string
+	keywords []string
+}
+
+func newPdfMetadata(project *data.Project, meta documentMetadata) pdfMetadata {
+	m := pdfMetadata{
+		title:    meta.String("title"),
+		author:   meta.String("author"),
+		subject:  project.Pdf.Subject,
+		keywords: project.Pdf.Keywords,
+	}
+
+	if m.title == "" {
+		m.title = project.Name
+	}
+	if m.author == "" {
+		m.author = project.Author
+	}
+	if m.subject == "" {
+		m.subject = meta.String("subject")
+	}
+	if m.subject == "" {
+		m.subject = meta.String("description")
+	}
+	m.keywords = append(m.keywords, meta.Strings("keywords")...)
+
+	return m
+}
+
+func (m pdfMetadata) args() []string {
+	var args []string
+
+	if m.title != "" {
+		args = append(args, "-V", "title-meta="+m.title)
+	}
+	if m.author != "" {
+		args = append(args, "-V", "author-meta="+m.author)
+	}
+	if m.subject != "" {
+		args = append(args, "-V", "subject="+m.subject)
+	}
+	for _, keyword := range m.keywords {
+		args = append(args, "-V", "keywords="+keyword)
+	}
+
+	return args
+}
+
+func pdfMetadataHeader(project *data.Project) (string, error) {
+	var b strings.Builder
+
+	if project.Pdf.BookmarkDepth > 0 {
+		b.WriteString("\\makeatletter\n")
+		fmt.Fprintf(&b, "\\@ifpackageloaded{bookmark}{\\bookmarksetup{depth=%d}}{\\hypersetup{bookmarksdepth=%d}}\n",
+			project.Pdf.BookmarkDepth, project.Pdf.BookmarkDepth)
+		b.WriteString("\\makeatother\n")
+	}
+
+	switch project.Pdf.PdfA {
+	case "":
+		b.WriteString("\\IfFileExists{hyperxmp.sty}{\\usepackage{hyperxmp}}{}\n")
+	case "1b", "2b":
+		part := project.Pdf.PdfA[:1]
+		minor := 4
+		if part == "2" {
+			minor = 7
+		}
+
+		fmt.Fprintf(&b, "\\ifdefined\\pdfminorversion\\pdfminorversion=%d\\fi\n", minor)
+		if part == "1" {
+			b.WriteString("\\ifdefined\\pdfobjcompresslevel\\pdfobjcompresslevel=0\\fi\n")
+		}
+		b.WriteString("\\usepackage{hyperxmp}\n")
+		fmt.Fprintf(&b, "\\hypersetup{pdfapart=%s,pdfaconformance=B}\n", part)
+		b.WriteString("\\usepackage{colorprofiles}\n")
+		b.WriteString("\\ifdefined\\pdfcatalog\n")
+		b.WriteString("\\immediate\\pdfobj stream attr{/N 3} file{sRGB.icc}\n")
+		fmt.Fprintf(&b, "\\pdfcatalog{/OutputIntents [<< /Type /OutputIntent /S /GTS_PDFA1 /DestOutputProfile \\the\\pdflastobj\\space 0 R /OutputConditionIdentifier (sRGB IEC61966-2.1) /Info (sRGB IEC61966-2.1) >>]}\n")
+		b.WriteString("\\fi\n")
+	default:
+		return "", fmt.Errorf("unsupported pdf/a mode '%s', use 1b or 2b", project.Pdf.PdfA)
+	}
+
+	if project.Version != "" {
+		b.WriteString("\\makeatletter\n")
+		fmt.Fprintf(&b, "\\@ifpackageloaded{hyperxmp}{\\hypersetup{pdfversionid={%s}}}{}\n", escapeLatex(project.Version))
+		b.WriteString("\\makeatother\n")
+	}
+
+	return b.String(), nil
+}
+
+func checkPdfMetadata(filePath string, m pdfMetadata) error {
+	chunks, err := readPdfChunks(filePath)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	if m.title != "" && !pdfHasInfo(chunks, "Title", "dc:title", m.title) {
+		missing = append(missing, "title")
+	}
+	if m.author != "" && !pdfHasInfo(chunks, "Author", "dc:creator", m.author) {
+		missing = append(missing, "author")
+	}
+	if len(m.keywords) > 0 && !pdfHasInfo(chunks, "Keywords", "pdf:Keywords", m.keywords[0]) {
+		missing = append(missing, "keywords")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("pdf metadata missing from %s: %s", filePath, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func readPdfChunks(filePath string) ([][]byte, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := [][]byte{content}
+	for _, stream := range rePdfStream.FindAllSubmatch(content, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			continue
+		}
+
+		decoded, err := io.ReadAll(r)
+		r.Close()
+		if err != nil && len(decoded) == 0 {
+			continue
+		}
+
+		chunks = append(chunks, decoded)
+	}
+
+	return chunks, nil
+}
+
+func pdfHasInfo(chunks [][]byte, key, xmpKey, val string) bool {
+	for _, chunk := range chunks {
+		for _, s := range pdfInfoStrings(chunk, key) {
+			if strings.Contains(s, val) {
+				return true
+			}
+		}
+
+		if idx := bytes.Index(chunk, []byte("<"+xmpKey)); idx >= 0 {
+			end := bytes.Index(chunk[idx:], []byte("</"+xmpKey+">"))
+			if end > 0 && bytes.Contains(chunk[idx:idx+end], []byte(val)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func pdfInfoStrings(chunk []byte, key string) []string {
+	var values []string
+
+	needle := []byte("/" + key)
+	for idx := bytes.Index(chunk, needle); idx >= 0; {
+		rest := bytes.TrimLeft(chunk[idx+len(needle):], " \r\n\t")
+		if len(rest) > 0 {
+			switch rest[0] {
+			case '(':
+				values = append(values, decodePdfText(parsePdfLiteral(rest)))
+			case '<':
+				values = append(values, decodePdfText(parsePdfHex(rest)))
+			}
+		}
+
+		next := bytes.Index(chunk[idx+len(needle):], needle)
+		if next < 0 {
+			break
+		}
+		idx += len(needle) + next
+	}
+
+	return values
+}
+
+func parsePdfLiteral(b []byte) []byte {
+	var out []byte
+	depth := 0
+
+	for i := 1; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '\\':
+			if i+1 >= len(b) {
+				return out
+			}
+			i++
+			switch b[i] {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				j := i
+				for j < len(b) && j < i+3 && b[j] >= '0' && b[j] <= '7' {
+					j++
+				}
+				val, _ := strconv.ParseUint(string(b[i:j]), 8, 8)
+				out = append(out, byte(val))
+				i = j - 1
+			default:
+				out = append(out, b[i])
+			}
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			if depth == 0 {
+				return out
+			}
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+func parsePdfHex(b []byte) []byte {
+	end := bytes.IndexByte(b, '>')
+	if end < 0 {
+		return nil
+	}
+
+	hex := strings.Join(strings.Fields(string(b[1:end])), "")
+	if len(hex)%2 == 1 {
+		hex += "0"
+	}
+
+	var out []byte
+	for i := 0; i+1 < len(hex); i += 2 {
+		val, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return nil
+		}
+		out = append(out, byte(val))
+	}
+
+	return out
+}
+
+func decodePdfText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		var units []uint16
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	return string(b)
+}
+
+func escapeLatex(s string) string {
+	return strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`#`, `\#`,
+		`$`, `\$`,
+		`%`, `\%`,
+		`&`, `\&`,
+		`_`, `\_`,
+		`^`, `\^{}`,
+		`~`, `\~{}`,
+	).Replace(s)
+}
@@ -0,0 +1,335 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+const (
+	mmToBp = 72 / 25.4
+	inToBp = 72.0
+
+	cropMarksMargin = 10 * mmToBp
+)
+
+var (
+	rePdfPages    = regexp.MustCompile(`<<[^<>]*?/Type\s*/Pages\b[^<>]*?>>`)
+	rePdfCount    = regexp.MustCompile(`/Count\s+(\d+)`)
+	rePdfMediaBox = regexp.MustCompile(`/MediaBox\s*\[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+	reNup         = regexp.MustCompile(`^(\d+)x(\d+)$`)
+	reLength      = regexp.MustCompile(`^([\d.]+)\s*(mm|cm|in|pt|bp)?$`)
+)
+
+var paperSizes = map[string][2]float64{
+	"a4":     {210 * mmToBp, 297 * mmToBp},
+	"a5":     {148 * mmToBp, 210 * mmToBp},
+	"a6":     {105 * mmToBp, 148 * mmToBp},
+	"b5":     {176 * mmToBp, 250 * mmToBp},
+	"letter": {8.5 * inToBp, 11 * inToBp},
+	"legal":  {8.5 * inToBp, 14 * inToBp},
+}
+
+func buildPdfPrint(project *data.Project) error {
+	cfg := &project.Pdf.Print
+	outDir := path.Join(project.OutputFolder, project.Pdf.OutputFolder)
+	input := path.Join(outDir, project.Pdf.OutputFileName)
+
+	output := cfg.OutputFileName
+	if output == "" {
+		output = strings.TrimSuffix(project.Pdf.OutputFileName, path.Ext(project.Pdf.OutputFileName)) + "-print.pdf"
+	}
+
+	content, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+
+	pages, width, height, err := pdfPageInfo(input)
+	if err != nil {
+		return err
+	}
+
+	bleed, err := printBleed(cfg)
+	if err != nil {
+		return err
+	}
+
+	// document is rendered with bleed, media box is trim size plus bleed
+	width -= 2 * bleed
+	height -= 2 * bleed
+
+	if cfg.TrimSize != "" {
+		width, height, err = parsePaperSize(cfg.TrimSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	cols, rows := 1, 1
+	order := pageRange(pages)
+	switch cfg.Imposition {
+	case "":
+	case "booklet":
+		if pages%4 != 0 {
+			utils.PrintWarning(fmt.Sprintf("booklet has %d pages which is not divisible by 4, blank pages added", pages))
+		}
+		cols = 2
+		order = bookletOrder(pages)
+	default:
+		m := reNup.FindStringSubmatch(cfg.Imposition)
+		if m == nil {
+			return fmt.Errorf("unsupported imposition '%s', use booklet or n-up like 2x1", cfg.Imposition)
+		}
+		cols, _ = strconv.Atoi(m[1])
+		rows, _ = strconv.Atoi(m[2])
+		if cols < 1 || rows < 1 {
+			return fmt.Errorf("unsupported imposition '%s'", cfg.Imposition)
+		}
+	}
+
+	pagebox := "cropbox"
+	if bleed > 0 {
+		if cols*rows == 1 {
+			pagebox = "mediabox"
+		} else {
+			utils.PrintWarning("bleed is used only without imposition, imposed pages are trimmed")
+			bleed = 0
+		}
+	}
+
+	sheetWidth := width * float64(cols)
+	sheetHeight := height * float64(rows)
+
+	margin := bleed
+	marks := "off"
+	if cfg.CropMarks {
+		margin += cropMarksMargin
+		marks = "cam"
+	}
+
+	var b strings.Builder
+	b.WriteString(latexPreamble())
+	b.WriteString("\\documentclass{article}\n")
+	fmt.Fprintf(&b, "\\usepackage[papersize={%.2fbp,%.2fbp},margin=0pt]{geometry}\n", sheetWidth, sheetHeight)
+	if margin > 0 {
+		fmt.Fprintf(&b, "\\usepackage[%s,center,pdftex,width=%.2fbp,height=%.2fbp]{crop}\n",
+			marks, sheetWidth+2*margin, sheetHeight+2*margin)
+	}
+	b.WriteString("\\usepackage{pdfpages}\n")
+	b.WriteString("\\begin{document}\n")
+	fmt.Fprintf(&b, "\\includepdf[pages={%s},nup=%dx%d,noautoscale,pagebox=%s]{input.pdf}\n",
+		strings.Join(order, ","), cols, rows, pagebox)
+	b.WriteString("\\end{document}\n")
+
+	dir, err := os.MkdirTemp("", "author-print-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.WriteFile(path.Join(dir, "input.pdf"), content, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = runLatex(dir, "print", b.String())
+	if err != nil {
+		return err
+	}
+
+	result, err := os.ReadFile(path.Join(dir, "print.pdf"))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(outDir, output), result, 0644)
+}
+
+func printBleed(cfg *data.ProjectPdfPrint) (float64, error) {
+	if !cfg.Enabled || cfg.Bleed == "" {
+		return 0, nil
+	}
+
+	return parseLength(cfg.Bleed)
+}
+
+// pdfBleedHeader renders document on paper enlarged by bleed on every side.
+// Text stays on trim sized layout, while backgrounds drawn on the whole paper
+// reach bleed edge. Crop and trim boxes keep the screen pdf at trim size.
+func pdfBleedHeader(project *data.Project) (string, error) {
+	bleed, err := printBleed(&project.Pdf.Print)
+	if err != nil || bleed == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("\\makeatletter\n")
+	b.WriteString("\\@ifpackageloaded{geometry}{}{\\usepackage{geometry}}\n")
+	b.WriteString("\\newlength\\authorbleed\\setlength\\authorbleed{" + fmt.Sprintf("%.2fbp", bleed) + "}\n")
+	b.WriteString("\\newlength\\authortrimwidth\\newlength\\authortrimheight\n")
+	if project.Pdf.Print.TrimSize != "" {
+		width, height, err := parsePaperSize(project.Pdf.Print.TrimSize)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\\setlength\\authortrimwidth{%.2fbp}\\setlength\\authortrimheight{%.2fbp}\n", width, height)
+	} else {
+		b.WriteString("\\setlength\\authortrimwidth{\\paperwidth}\\setlength\\authortrimheight{\\paperheight}\n")
+	}
+	b.WriteString("\\geometry{layoutwidth=\\authortrimwidth,layoutheight=\\authortrimheight," +
+		"layouthoffset=\\authorbleed,layoutvoffset=\\authorbleed," +
+		"paperwidth=\\dimexpr\\authortrimwidth+2\\authorbleed\\relax," +
+		"paperheight=\\dimexpr\\authortrimheight+2\\authorbleed\\relax}\n")
+	b.WriteString("\\def\\authorbp#1{\\strip@pt\\dimexpr(#1)*7200/7227\\relax}\n")
+	b.WriteString("\\AtBeginDocument{\\edef\\authorboxes{" +
+		"/CropBox [\\authorbp\\authorbleed\\space\\authorbp\\authorbleed\\space" +
+		"\\authorbp{\\paperwidth-\\authorbleed}\\space\\authorbp{\\paperheight-\\authorbleed}]" +
+		" /TrimBox [\\authorbp\\authorbleed\\space\\authorbp\\authorbleed\\space" +
+		"\\authorbp{\\paperwidth-\\authorbleed}\\space\\authorbp{\\paperheight-\\authorbleed}]" +
+		" /BleedBox [0 0 \\authorbp\\paperwidth\\space\\authorbp\\paperheight]}" +
+		"\\ifdefined\\pdfpageattr\\pdfpageattr\\expandafter{\\the\\pdfpageattr\\space\\authorboxes}\\fi}\n")
+	b.WriteString("\\makeatother\n")
+
+	return b.String(), nil
+}
+
+func pdfPageInfo(filePath string) (int, float64, float64, error) {
+	chunks, err := readPdfChunks(filePath)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	pages := 0
+	width, height := 0.0, 0.0
+	for _, chunk := range chunks {
+		for _, dict := range rePdfPages.FindAll(chunk, -1) {
+			if m := rePdfCount.FindSubmatch(dict); m != nil {
+				count, _ := strconv.Atoi(string(m[1]))
+				pages = max(pages, count)
+			}
+		}
+
+		if width == 0 {
+			if m := rePdfMediaBox.FindSubmatch(chunk); m != nil {
+				var box [4]float64
+				for i := range box {
+					box[i], _ = strconv.ParseFloat(string(m[i+1]), 64)
+				}
+				width = box[2] - box[0]
+				height = box[3] - box[1]
+			}
+		}
+	}
+
+	if pages == 0 || width <= 0 || height <= 0 {
+		return 0, 0, 0, fmt.Errorf("unable to read page count and size from %s", filePath)
+	}
+
+	return pages, width, height, nil
+}
+
+func pageRange(pages int) []string {
+	order := make([]string, pages)
+	for i := range order {
+		order[i] = strconv.Itoa(i + 1)
+	}
+
+	return order
+}
+
+func bookletOrder(pages int) []string {
+	total := (pages + 3) / 4 * 4
+	page := func(n int) string {
+		if n > pages {
+			return "{}"
+		}
+		return strconv.Itoa(n)
+	}
+
+	var order []string
+	for sheet := 0; sheet < total/4; sheet++ {
+		order = append(order,
+			page(total-2*sheet), page(2*sheet+1),
+			page(2*sheet+2), page(total-2*sheet-1),
+		)
+	}
+
+	return order
+}
+
+func parsePaperSize(size string) (float64, float64, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if dims, ok := paperSizes[size]; ok {
+		return dims[0], dims[1], nil
+	}
+
+	parts := strings.Split(size, "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid trim size '%s'", size)
+	}
+
+	width, err := parseLength(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	height, err := parseLength(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return width, height, nil
+}
+
+func parseLength(length string) (float64, error) {
+	m := reLength.FindStringSubmatch(strings.TrimSpace(length))
+	if m == nil {
+		return 0, fmt.Errorf("invalid length '%s'", length)
+	}
+
+	val, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length '%s'", length)
+	}
+
+	switch m[2] {
+	case "", "mm":
+		return val * mmToBp, nil
+	case "cm":
+		return val * 10 * mmToBp, nil
+	case "in":
+		return val * inToBp, nil
+	case "pt":
+		return val * 72 / 72.27, nil
+	default:
+		return val, nil
+	}
+}
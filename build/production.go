@@ -0,0 +1,636 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const assetManifestFileName = "asset-manifest.json"
+
+// wellKnownFiles are looked up by fixed name in site root, so they are never
+// fingerprinted.
+var wellKnownFiles = []string{"robots.txt", "sitemap.xml", "favicon.ico", assetManifestFileName}
+
+var (
+	reCssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	reCssUrl     = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+	reCssImport  = regexp.MustCompile(`@import\s*(['"])([^'"]+)['"]`)
+	reHtmlSpace  = regexp.MustCompile(`\s+`)
+)
+
+type assetManifest map[string]string
+
+func buildHtmlProduction(dst string) error {
+	err := removeFingerprintedAssets(dst)
+	if err != nil {
+		return err
+	}
+
+	err = minifyHtmlAssets(dst)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := fingerprintHtmlAssets(dst)
+	if err != nil {
+		return err
+	}
+
+	err = rewriteHtmlPages(dst, manifest)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(dst, assetManifestFileName), content, 0644)
+}
+
+func removeFingerprintedAssets(dst string) error {
+	content, err := os.ReadFile(path.Join(dst, assetManifestFileName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	manifest := assetManifest{}
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, hashed := range manifest {
+		err = os.Remove(path.Join(dst, hashed))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func minifyHtmlAssets(dst string) error {
+	return fs.WalkDir(os.DirFS(dst), ".", func(pth string, dir fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dir.IsDir() {
+			return nil
+		}
+
+		var minify func([]byte) ([]byte, error)
+		switch {
+		case strings.HasSuffix(pth, ".min.css"), strings.HasSuffix(pth, ".min.js"):
+			return nil
+		case strings.HasSuffix(pth, ".html"):
+			minify = minifyHtml
+		case strings.HasSuffix(pth, ".css"):
+			minify = minifyCss
+		case strings.HasSuffix(pth, ".js"):
+			minify = minifyJs
+		default:
+			return nil
+		}
+
+		filePath := path.Join(dst, pth)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		content, err = minify(content)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(filePath, content, 0644)
+	})
+}
+
+func minifyHtml(content []byte) ([]byte, error) {
+	node, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	minifyHtmlNode(node)
+
+	var buf bytes.Buffer
+	err = html.Render(&buf, node)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func minifyHtmlNode(node *html.Node) {
+	var nn *html.Node
+	for n := node.FirstChild; n != nil; n = nn {
+		nn = n.NextSibling
+
+		switch n.Type {
+		case html.CommentNode:
+			if !strings.HasPrefix(n.Data, "[if") {
+				node.RemoveChild(n)
+			}
+		case html.TextNode:
+			n.Data = reHtmlSpace.ReplaceAllString(n.Data, " ")
+		case html.ElementNode:
+			switch n.Data {
+			case "pre", "textarea", "script", "style", "code":
+				continue
+			}
+			minifyHtmlNode(n)
+		}
+	}
+}
+
+func minifyCss(content []byte) ([]byte, error) {
+	var out []byte
+	space := false
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		switch {
+		case c == '"' || c == '\'':
+			end := skipQuoted(content, i)
+			out = appendCssSpace(out, space, c)
+			out = append(out, content[i:end]...)
+			space = false
+			i = end - 1
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+			space = true
+		case isSpace(c):
+			space = true
+		default:
+			if c == '}' && len(out) > 0 && out[len(out)-1] == ';' {
+				out = out[:len(out)-1]
+			}
+			out = appendCssSpace(out, space, c)
+			out = append(out, c)
+			space = false
+		}
+	}
+
+	return out, nil
+}
+
+func appendCssSpace(out []byte, space bool, next byte) []byte {
+	if !space || len(out) == 0 || strings.IndexByte("{};,", next) >= 0 ||
+		strings.IndexByte("{};,", out[len(out)-1]) >= 0 {
+		return out
+	}
+
+	return append(out, ' ')
+}
+
+// minifyJs removes comments and whitespace which is not needed. Line breaks
+// are kept, so automatic semicolon insertion works as in original script.
+func minifyJs(content []byte) ([]byte, error) {
+	var out []byte
+	space, newline := false, false
+
+	emit := func(token []byte) {
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			switch {
+			case newline:
+				out = append(out, '\n')
+			case space && needsJsSpace(last, token[0]):
+				out = append(out, ' ')
+			}
+		}
+
+		out = append(out, token...)
+		space, newline = false, false
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end := skipQuoted(content, i)
+			emit(content[i:end])
+			i = end - 1
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			end := bytes.IndexByte(content[i:], '\n')
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end - 1
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			comment := content[i:]
+			if end >= 0 {
+				comment = content[i : i+end+4]
+			}
+			if bytes.IndexByte(comment, '\n') >= 0 {
+				newline = len(out) > 0
+			}
+			space = true
+			i += len(comment) - 1
+		case c == '/' && isJsRegexStart(out):
+			end := skipJsRegex(content, i)
+			emit(content[i:end])
+			i = end - 1
+		case c == '\n':
+			newline = len(out) > 0
+		case isSpace(c):
+			space = true
+		default:
+			emit(content[i : i+1])
+		}
+	}
+
+	return out, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isJsIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func needsJsSpace(prev, next byte) bool {
+	if isJsIdent(prev) && isJsIdent(next) {
+		return true
+	}
+
+	return (prev == '+' || prev == '-') && prev == next
+}
+
+// isJsRegexStart reports whether slash after already minified output starts
+// regular expression literal instead of division.
+func isJsRegexStart(out []byte) bool {
+	out = bytes.TrimRight(out, " \n")
+	if len(out) == 0 {
+		return true
+	}
+
+	last := out[len(out)-1]
+	if strings.IndexByte("(,=:[!&|?{};+-*%<>~^", last) >= 0 {
+		return true
+	}
+
+	for _, keyword := range []string{"return", "typeof", "case", "do", "else", "in", "of", "void", "yield"} {
+		if bytes.HasSuffix(out, []byte(keyword)) {
+			rest := out[:len(out)-len(keyword)]
+			if len(rest) == 0 || !isJsIdent(rest[len(rest)-1]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func skipJsRegex(content []byte, start int) int {
+	class := false
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '\n':
+			return i
+		case '/':
+			if !class {
+				i++
+				for i < len(content) && isJsIdent(content[i]) {
+					i++
+				}
+				return i
+			}
+		}
+	}
+
+	return len(content)
+}
+
+// skipQuoted returns index after string literal which starts at start.
+// Template literal placeholders may contain nested literals.
+func skipQuoted(content []byte, start int) int {
+	quote := content[start]
+	for i := start + 1; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '\\':
+			i++
+		case c == quote:
+			return i + 1
+		case quote == '`' && c == '$' && i+1 < len(content) && content[i+1] == '{':
+			depth := 0
+			for i++; i < len(content); i++ {
+				switch content[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				case '"', '\'', '`':
+					i = skipQuoted(content, i) - 1
+				}
+				if depth == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	return len(content)
+}
+
+func fingerprintHtmlAssets(dst string) (assetManifest, error) {
+	manifest := assetManifest{}
+	styles := map[string][]string{}
+
+	err := fs.WalkDir(os.DirFS(dst), ".", func(pth string, dir fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dir.IsDir() {
+			if pth != "." && strings.HasPrefix(dir.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if slices.Contains(wellKnownFiles, pth) || strings.HasPrefix(dir.Name(), ".") || strings.HasSuffix(pth, ".html") {
+			return nil
+		}
+
+		if strings.HasSuffix(pth, ".css") {
+			styles[pth] = nil
+			return nil
+		}
+
+		return fingerprintAsset(dst, pth, manifest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for style := range styles {
+		content, err := os.ReadFile(path.Join(dst, style))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range cssReferences(content, path.Dir(style)) {
+			if _, ok := styles[ref]; ok && ref != style {
+				styles[style] = append(styles[style], ref)
+			}
+		}
+	}
+
+	// stylesheet is fingerprinted after stylesheets it references, so its
+	// hash covers rewritten references
+	for len(styles) > 0 {
+		var ready []string
+		for style, deps := range styles {
+			if !slices.ContainsFunc(deps, func(dep string) bool {
+				_, pending := styles[dep]
+				return pending
+			}) {
+				ready = append(ready, style)
+			}
+		}
+
+		if len(ready) == 0 {
+			ready = slices.Collect(maps.Keys(styles))
+		}
+		slices.Sort(ready)
+
+		for _, style := range ready {
+			filePath := path.Join(dst, style)
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return nil, err
+			}
+
+			content = rewriteCssUrls(content, path.Dir(style), manifest)
+			err = os.WriteFile(filePath, content, 0644)
+			if err != nil {
+				return nil, err
+			}
+
+			err = fingerprintAsset(dst, style, manifest)
+			if err != nil {
+				return nil, err
+			}
+
+			delete(styles, style)
+		}
+	}
+
+	return manifest, nil
+}
+
+func cssReferences(content []byte, dir string) []string {
+	var refs []string
+	for _, re := range []*regexp.Regexp{reCssUrl, reCssImport} {
+		for _, m := range re.FindAllSubmatch(content, -1) {
+			ref := string(m[2])
+			if idx := strings.IndexAny(ref, "?#"); idx >= 0 {
+				ref = ref[:idx]
+			}
+			refs = append(refs, path.Join(dir, ref))
+		}
+	}
+
+	return refs
+}
+
+func fingerprintAsset(dst, pth string, manifest assetManifest) error {
+	filePath := path.Join(dst, pth)
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(content)
+	ext := path.Ext(pth)
+	hashed := strings.TrimSuffix(pth, ext) + "." + hex.EncodeToString(sum[:])[:8] + ext
+
+	err = os.Rename(filePath, path.Join(dst, hashed))
+	if err != nil {
+		return err
+	}
+
+	manifest[pth] = hashed
+	return nil
+}
+
+func rewriteCssUrls(content []byte, dir string, manifest assetManifest) []byte {
+	content = reCssUrl.ReplaceAllFunc(content, func(match []byte) []byte {
+		m := reCssUrl.FindSubmatch(match)
+		ref := string(m[2])
+
+		hashed, ok := lookupAsset(dir, ref, manifest)
+		if !ok {
+			return match
+		}
+
+		return []byte("url(" + string(m[1]) + hashed + string(m[3]) + ")")
+	})
+
+	return reCssImport.ReplaceAllFunc(content, func(match []byte) []byte {
+		m := reCssImport.FindSubmatch(match)
+		ref := string(m[2])
+
+		hashed, ok := lookupAsset(dir, ref, manifest)
+		if !ok {
+			return match
+		}
+
+		return []byte("@import " + string(m[1]) + hashed + string(m[1]))
+	})
+}
+
+func rewriteHtmlPages(dst string, manifest assetManifest) error {
+	return fs.WalkDir(os.DirFS(dst), ".", func(pth string, dir fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dir.IsDir() || !strings.HasSuffix(pth, ".html") {
+			return nil
+		}
+
+		filePath := path.Join(dst, pth)
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+
+		node, err := html.Parse(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		rewriteHtmlNode(node, path.Dir(pth), manifest)
+
+		f, err = os.Create(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return html.Render(f, node)
+	})
+}
+
+func rewriteHtmlNode(node *html.Node, dir string, manifest assetManifest) {
+	if node.Type == html.ElementNode {
+		for i := range node.Attr {
+			attr := &node.Attr[i]
+
+			switch attr.Key {
+			case "href", "src", "poster", "data-src":
+				if hashed, ok := lookupAsset(dir, attr.Val, manifest); ok {
+					attr.Val = hashed
+				}
+			case "srcset":
+				candidates := strings.Split(attr.Val, ",")
+				for j, candidate := range candidates {
+					fields := strings.Fields(candidate)
+					if len(fields) == 0 {
+						continue
+					}
+
+					if hashed, ok := lookupAsset(dir, fields[0], manifest); ok {
+						fields[0] = hashed
+					}
+					candidates[j] = strings.Join(fields, " ")
+				}
+				attr.Val = strings.Join(candidates, ", ")
+			}
+		}
+
+		if node.Data == "style" && node.FirstChild != nil {
+			node.FirstChild.Data = string(rewriteCssUrls([]byte(node.FirstChild.Data), dir, manifest))
+		}
+	}
+
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		rewriteHtmlNode(n, dir, manifest)
+	}
+}
+
+func lookupAsset(dir, ref string, manifest assetManifest) (string, bool) {
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "/") || strings.Contains(ref, ":") {
+		return "", false
+	}
+
+	suffix := ""
+	if idx := strings.IndexAny(ref, "?#"); idx >= 0 {
+		suffix = ref[idx:]
+		ref = ref[:idx]
+	}
+
+	hashed, ok := manifest[path.Join(dir, ref)]
+	if !ok {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(dir, hashed)
+	if err != nil {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel) + suffix, true
+}
@@ -0,0 +1,277 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+	"golang.org/x/net/html"
+)
+
+type sitemapUrl struct {
+	Loc string `xml:"loc"`
+}
+
+type sitemap struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+func (p *processHtml) postProcessHtmlHead(node *html.Node) {
+	title := p.meta.String("title")
+	description := p.meta.String("description")
+	author := p.meta.String("author")
+	if author == "" {
+		author = p.project.Author
+	}
+	if title == "" {
+		title = p.project.Name
+	}
+
+	siteUrl := projectSiteUrl(p.project)
+	pageUrl := ""
+	if siteUrl != "" {
+		pageUrl = sitePageUrl(siteUrl, p.page)
+	}
+
+	image := p.meta.String("cover-image")
+	if image == "" {
+		image = p.meta.String("image")
+	}
+	if image != "" && siteUrl != "" && !strings.Contains(image, "://") {
+		image = siteUrl + "/" + strings.TrimPrefix(image, "/")
+	}
+
+	ogType := "article"
+	if p.isBook() {
+		ogType = "book"
+	}
+
+	if description != "" && !hasHtmlMeta(node, "name", "description") {
+		appendHtmlMeta(node, "name", "description", description)
+	}
+
+	appendHtmlMeta(node, "property", "og:type", ogType)
+	appendHtmlMeta(node, "property", "og:title", title)
+	appendHtmlMeta(node, "property", "og:description", description)
+	appendHtmlMeta(node, "property", "og:url", pageUrl)
+	appendHtmlMeta(node, "property", "og:image", image)
+
+	card := "summary"
+	if image != "" {
+		card = "summary_large_image"
+	}
+	appendHtmlMeta(node, "name", "twitter:card", card)
+	appendHtmlMeta(node, "name", "twitter:title", title)
+	appendHtmlMeta(node, "name", "twitter:description", description)
+	appendHtmlMeta(node, "name", "twitter:image", image)
+
+	if pageUrl != "" {
+		node.AppendChild(&html.Node{
+			Type: html.ElementNode,
+			Data: "link",
+			Attr: []html.Attribute{
+				{Key: "rel", Val: "canonical"},
+				{Key: "href", Val: pageUrl},
+			},
+		})
+	}
+
+	ld := map[string]any{
+		"@context": "https://schema.org",
+		"@type":    "ScholarlyArticle",
+	}
+	if p.isBook() {
+		ld["@type"] = "Book"
+		ld["name"] = title
+	} else {
+		ld["headline"] = title
+	}
+	if author != "" {
+		ld["author"] = map[string]any{
+			"@type": "Person",
+			"name":  author,
+		}
+	}
+	setJsonLd(ld, "description", description)
+	setJsonLd(ld, "datePublished", p.meta.String("date"))
+	setJsonLd(ld, "inLanguage", p.meta.String("lang"))
+	setJsonLd(ld, "version", p.project.Version)
+	setJsonLd(ld, "url", pageUrl)
+	setJsonLd(ld, "image", image)
+
+	content, err := json.MarshalIndent(ld, "", "  ")
+	if err != nil {
+		return
+	}
+
+	script := &html.Node{
+		Type: html.ElementNode,
+		Data: "script",
+		Attr: []html.Attribute{
+			{Key: "type", Val: "application/ld+json"},
+		},
+	}
+	script.AppendChild(&html.Node{
+		Type: html.TextNode,
+		Data: string(content),
+	})
+	node.AppendChild(script)
+}
+
+func (p *processHtml) isBook() bool {
+	if p.meta.Bool("book") {
+		return true
+	}
+
+	if p.project.Pdf.TopLevelDivision == "chapter" {
+		return true
+	}
+
+	// projects which were not migrated keep division in pandoc args
+	return slices.Contains(p.project.Pdf.Args, "--top-level-division=chapter")
+}
+
+func hasHtmlMeta(node *html.Node, key, name string) bool {
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode || n.Data != "meta" {
+			continue
+		}
+
+		if val, _ := utils.GetHtmlAttribute(n, key); val == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendHtmlMeta(node *html.Node, key, name, content string) {
+	if content == "" || hasHtmlMeta(node, key, name) {
+		return
+	}
+
+	node.AppendChild(&html.Node{
+		Type: html.ElementNode,
+		Data: "meta",
+		Attr: []html.Attribute{
+			{Key: key, Val: name},
+			{Key: "content", Val: content},
+		},
+	})
+}
+
+func setJsonLd(ld map[string]any, key, val string) {
+	if val != "" {
+		ld[key] = val
+	}
+}
+
+// projectSiteUrl returns public url of html output without trailing slash,
+// or empty string when siteUrl is not set. Language folder is appended for
+// translations.
+func projectSiteUrl(project *data.Project) string {
+	if project.SiteUrl == "" {
+		return ""
+	}
+
+	siteUrl := strings.TrimSuffix(project.SiteUrl, "/")
+	if project.SitePath != "" {
+		siteUrl += "/" + project.SitePath
+	}
+
+	return siteUrl
+}
+
+// sitePageUrl returns public url of page, page is path relative to html
+// output folder.
+func sitePageUrl(siteUrl, page string) string {
+	page = strings.TrimPrefix(page, "./")
+	if page == "index.html" {
+		return siteUrl + "/"
+	}
+	if strings.HasSuffix(page, "/index.html") {
+		page = strings.TrimSuffix(page, "index.html")
+	}
+
+	return siteUrl + "/" + page
+}
+
+func writeSitemap(project *data.Project) error {
+	siteUrl := projectSiteUrl(project)
+	if siteUrl == "" {
+		utils.PrintWarning("siteUrl is not set, canonical links and sitemap are skipped")
+		return nil
+	}
+
+	dst := path.Join(project.OutputFolder, project.Html.OutputFolder)
+
+	var pages []string
+	err := fs.WalkDir(os.DirFS(dst), ".", func(pth string, dir fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !dir.IsDir() && strings.HasSuffix(pth, ".html") {
+			pages = append(pages, pth)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(pages) < 2 {
+		return nil
+	}
+
+	s := sitemap{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+	}
+	for _, page := range pages {
+		s.Urls = append(s.Urls, sitemapUrl{Loc: sitePageUrl(siteUrl, page)})
+	}
+
+	content, err := xml.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path.Join(dst, "sitemap.xml"), append([]byte(xml.Header), content...), 0644)
+	if err != nil {
+		return err
+	}
+
+	robots := fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %s/sitemap.xml\n", siteUrl)
+	return os.WriteFile(path.Join(dst, "robots.txt"), []byte(robots), 0644)
+}
@@ -0,0 +1,424 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type sourceOptions struct {
+	draft  bool
+	split  string
+	rebase bool
+	dir    string
+}
+
+type sourceIssue struct {
+	file    string
+	line    int
+	heading string
+	message string
+}
+
+type sourceHeading struct {
+	file  string
+	line  int
+	level int
+	text  string
+}
+
+// headingRef points to heading by its text and how many headings with the
+// same text come before it, so repeated titles like Introduction in every
+// chapter are told apart.
+type headingRef struct {
+	text       string
+	occurrence int
+}
+
+// headingCounter counts headings with the same text while html is walked.
+type headingCounter map[string]int
+
+func (c headingCounter) next(text string) headingRef {
+	ref := headingRef{text: normalizeHeadingText(text)}
+	ref.occurrence = c[ref.text]
+	c[ref.text]++
+
+	return ref
+}
+
+var (
+	reSlugInvalid       = regexp.MustCompile(`[^a-z0-9_-]+`)
+	reHeading           = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	reHeadingAttributes = regexp.MustCompile(`\s*\{[^}]*\}\s*$`)
+	reFence             = regexp.MustCompile("^\\s*(```|~~~)")
+	reMarkdownLink      = regexp.MustCompile(`(!?\[(?:[^\[\]]|\[[^\[\]]*\])*\]\(\s*)(<[^>]*>|[^\s)]+)`)
+	reMarkdownReference = regexp.MustCompile(`(?m)^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>]*>|\S+)`)
+)
+
+func scanSourceHeadings(srcs []string) ([]sourceHeading, error) {
+	var headings []sourceHeading
+
+	for _, src := range srcs {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+
+		fence := ""
+		frontMatter := false
+		lineNum := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lineNum++
+			line := scanner.Text()
+
+			if lineNum == 1 && line == "---" {
+				frontMatter = true
+				continue
+			}
+			if frontMatter {
+				if line == "---" || line == "..." {
+					frontMatter = false
+				}
+				continue
+			}
+
+			if m := reFence.FindStringSubmatch(line); m != nil {
+				if fence == "" {
+					fence = m[1]
+				} else if fence == m[1] {
+					fence = ""
+				}
+				continue
+			}
+			if fence != "" {
+				continue
+			}
+
+			m := reHeading.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+
+			headings = append(headings, sourceHeading{
+				file:  src,
+				line:  lineNum,
+				level: len(m[1]),
+				text:  normalizeHeadingText(reHeadingAttributes.ReplaceAllString(m[2], "")),
+			})
+		}
+
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return headings, nil
+}
+
+func normalizeHeadingText(text string) string {
+	text = strings.NewReplacer("*", "", "_", "", "`", "").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// findSourceHeading returns source heading at the same position among
+// headings with equal text, headings keep source order in html.
+func findSourceHeading(headings []sourceHeading, ref headingRef) *sourceHeading {
+	occurrence := ref.occurrence
+	for i := range headings {
+		if headings[i].text != ref.text {
+			continue
+		}
+
+		if occurrence == 0 {
+			return &headings[i]
+		}
+		occurrence--
+	}
+
+	return nil
+}
+
+func newSourceIssue(headings []sourceHeading, file string, heading headingRef, message string) sourceIssue {
+	issue := sourceIssue{
+		file:    file,
+		heading: heading.text,
+		message: message,
+	}
+
+	if h := findSourceHeading(headings, heading); h != nil {
+		issue.file = h.file
+		issue.line = h.line
+	}
+
+	return issue
+}
+
+func (i sourceIssue) String() string {
+	location := i.file
+	if i.line > 0 {
+		location = fmt.Sprintf("%s:%d", i.file, i.line)
+	}
+
+	if i.heading != "" {
+		return fmt.Sprintf("%s: [%s] %s", location, i.heading, i.message)
+	}
+
+	return fmt.Sprintf("%s: %s", location, i.message)
+}
+
+// prepareSources returns sources with review markers and split markers
+// processed. Changed sources are written to temporary folder, so user
+// sources are never touched. With rebase, relative links are rewritten to
+// stay relative to original source folder.
+func prepareSources(srcs []string, opts sourceOptions) (prepared []string, cleanup func(), err error) {
+	slugs := map[string]bool{}
+	dir := ""
+
+	cleanup = func() {
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	}
+	// temporary folder is removed on every error return
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	for _, src := range srcs {
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		processed := processReviewMarkers(string(content), opts.draft)
+
+		if opts.split != "" {
+			processed, err = insertSplitMarkers(src, processed, opts.split, slugs)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if processed == string(content) {
+			prepared = append(prepared, src)
+			continue
+		}
+
+		if dir == "" {
+			if opts.dir != "" {
+				err = os.MkdirAll(opts.dir, os.ModePerm)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			dir, err = os.MkdirTemp(opts.dir, "author-sources-*")
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if opts.rebase {
+			processed, err = rebaseMarkdownLinks(processed, path.Dir(src), dir)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		temp := path.Join(dir, fmt.Sprintf("%03d-%s", len(prepared), path.Base(src)))
+		err = os.WriteFile(temp, []byte(processed), 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		prepared = append(prepared, temp)
+	}
+
+	return prepared, cleanup, nil
+}
+
+// rebaseMarkdownLinks rewrites relative link and image destinations written
+// relative to srcDir, so they point to the same files from dstDir.
+func rebaseMarkdownLinks(content, srcDir, dstDir string) (string, error) {
+	absSrc, err := filepath.Abs(srcDir)
+	if err != nil {
+		return "", err
+	}
+
+	absDst, err := filepath.Abs(dstDir)
+	if err != nil {
+		return "", err
+	}
+
+	rebase := func(dest string) string {
+		target := strings.Trim(dest, "<>")
+		if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") ||
+			strings.Contains(target, ":") {
+			return dest
+		}
+
+		suffix := ""
+		if idx := strings.IndexAny(target, "?#"); idx >= 0 {
+			suffix = target[idx:]
+			target = target[:idx]
+		}
+
+		rel, err := filepath.Rel(absDst, filepath.Join(absSrc, target))
+		if err != nil {
+			return dest
+		}
+
+		rel = filepath.ToSlash(rel) + suffix
+		if strings.HasPrefix(dest, "<") {
+			return "<" + rel + ">"
+		}
+		return rel
+	}
+
+	replace := func(re *regexp.Regexp) func(string) string {
+		return func(text string) string {
+			return re.ReplaceAllStringFunc(text, func(match string) string {
+				m := re.FindStringSubmatch(match)
+				return m[1] + rebase(m[2])
+			})
+		}
+	}
+
+	content = mapOutsideFences(content, replace(reMarkdownLink))
+	return mapOutsideFences(content, replace(reMarkdownReference)), nil
+}
+
+// mapOutsideFences applies fn to parts of markdown which are not inside
+// fenced code blocks.
+func mapOutsideFences(content string, fn func(string) string) string {
+	var b strings.Builder
+	var chunk strings.Builder
+	fence := ""
+
+	flush := func() {
+		b.WriteString(fn(chunk.String()))
+		chunk.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if m := reFence.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				flush()
+				fence = m[1]
+			} else if fence == m[1] {
+				fence = ""
+				b.WriteString(line)
+				continue
+			}
+		}
+
+		if fence != "" {
+			b.WriteString(line)
+			continue
+		}
+
+		chunk.WriteString(line)
+	}
+	flush()
+
+	return b.String()
+}
+
+func insertSplitMarkers(src, content, mode string, slugs map[string]bool) (string, error) {
+	headings, err := scanSourceHeadings([]string{src})
+	if err != nil {
+		return "", err
+	}
+
+	markers := map[int]string{}
+	for _, heading := range headings {
+		if mode == "heading" && heading.level != 1 {
+			continue
+		}
+
+		slug := slugify(heading.text)
+		if slug == "" {
+			slug = slugify(strings.TrimSuffix(path.Base(src), path.Ext(src)))
+		}
+		if slug == "" {
+			slug = "chapter"
+		}
+
+		markers[heading.line] = uniqueSlug(slug, slugs)
+
+		if mode == "file" {
+			break
+		}
+	}
+
+	if len(markers) == 0 {
+		return content, nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+
+		if slug, ok := markers[i+1]; ok {
+			if !strings.HasSuffix(line, "\n") {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "\n```{=latex}\n\\authorsplit{%s}\n```\n", slug)
+		}
+	}
+
+	return b.String(), nil
+}
+
+var slugReplacer = strings.NewReplacer(
+	"č", "c", "ć", "c", "ž", "z", "š", "s", "đ", "dj",
+)
+
+func slugify(text string) string {
+	slug := strings.ToLower(strings.Join(strings.Fields(text), "-"))
+	slug = slugReplacer.Replace(slug)
+	slug = reSlugInvalid.ReplaceAllString(slug, "")
+
+	return strings.Trim(slug, "-")
+}
+
+func uniqueSlug(slug string, slugs map[string]bool) string {
+	unique := slug
+	for i := 2; slugs[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", slug, i)
+	}
+	slugs[unique] = true
+
+	return unique
+}
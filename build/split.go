@@ -0,0 +1,220 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+const splitFileName = ".author-split.txt"
+
+type pdfChapter struct {
+	slug  string
+	start int
+	end   int
+}
+
+func pdfSplitHeader(project *data.Project) (string, error) {
+	outDir, err := filepath.Abs(path.Join(project.OutputFolder, project.Pdf.OutputFolder))
+	if err != nil {
+		return "", err
+	}
+
+	splitFile := filepath.ToSlash(path.Join(outDir, splitFileName))
+
+	var b strings.Builder
+	b.WriteString("\\newwrite\\authorsplitfile\n")
+	fmt.Fprintf(&b, "\\immediate\\openout\\authorsplitfile=%s\n", splitFile)
+	b.WriteString("\\makeatletter\n")
+	b.WriteString("\\newcommand{\\authorsplit}[1]{\\write\\authorsplitfile{#1 \\the\\c@abspage}}\n")
+	b.WriteString("\\makeatother\n")
+
+	return b.String(), nil
+}
+
+func buildPdfSplit(project *data.Project) error {
+	outDir := path.Join(project.OutputFolder, project.Pdf.OutputFolder)
+	input := path.Join(outDir, project.Pdf.OutputFileName)
+	splitFile := path.Join(outDir, splitFileName)
+	defer os.Remove(splitFile)
+
+	pages, _, _, err := pdfPageInfo(input)
+	if err != nil {
+		return err
+	}
+
+	chapters, err := readPdfChapters(splitFile, pages)
+	if err != nil {
+		return err
+	}
+
+	if len(chapters) == 0 {
+		return fmt.Errorf("no chapters found to split, check that sources have headings")
+	}
+
+	content, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+
+	chaptersDir := path.Join(outDir, "chapters")
+	err = os.RemoveAll(chaptersDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(chaptersDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "author-split-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.WriteFile(path.Join(dir, "input.pdf"), content, 0644)
+	if err != nil {
+		return err
+	}
+
+	for _, chapter := range chapters {
+		var b strings.Builder
+		b.WriteString(latexPreamble())
+		b.WriteString("\\documentclass{article}\n")
+		b.WriteString("\\usepackage{pdfpages}\n")
+		b.WriteString("\\begin{document}\n")
+		fmt.Fprintf(&b, "\\includepdf[pages={%d-%d},fitpaper=true]{input.pdf}\n", chapter.start, chapter.end)
+		b.WriteString("\\end{document}\n")
+
+		err = runLatex(dir, "chapter", b.String())
+		if err != nil {
+			return fmt.Errorf("chapter '%s': %v", chapter.slug, err)
+		}
+
+		result, err := os.ReadFile(path.Join(dir, "chapter.pdf"))
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(path.Join(chaptersDir, chapter.slug+".pdf"), result, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readPdfChapters(splitFile string, pages int) ([]pdfChapter, error) {
+	f, err := os.Open(splitFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var chapters []pdfChapter
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		start, err := strconv.Atoi(fields[1])
+		if err != nil || start < 1 || start > pages {
+			continue
+		}
+
+		chapters = append(chapters, pdfChapter{
+			slug:  fields[0],
+			start: start,
+		})
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range chapters {
+		chapters[i].end = pages
+		if i+1 < len(chapters) {
+			chapters[i].end = max(chapters[i+1].start-1, chapters[i].start)
+		}
+	}
+
+	return chapters, nil
+}
+
+// buildPdfMerge joins before and after pdf files with pdf output. Pdf output
+// is primary document, so its outline, links and metadata are kept.
+func buildPdfMerge(project *data.Project, before, after []string) error {
+	outDir := path.Join(project.OutputFolder, project.Pdf.OutputFolder)
+	output := path.Join(outDir, project.Pdf.OutputFileName)
+
+	for _, file := range append(append([]string{}, before...), after...) {
+		if !utils.FileExists(file) {
+			return fmt.Errorf("pdf merge file '%s' not found", file)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "author-merge-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	merged := path.Join(dir, "merge.pdf")
+
+	files := append([]string{}, before...)
+	files = append(files, output)
+	files = append(files, after...)
+
+	if _, lookErr := exec.LookPath("qpdf"); lookErr == nil {
+		args := append([]string{output, "--pages"}, files...)
+		args = append(args, "--", merged)
+		err = runCommand(project.Dir, "qpdf", nil, args...)
+	} else if _, lookErr := exec.LookPath("pdfunite"); lookErr == nil {
+		utils.PrintWarning("qpdf not found, pdf merged with pdfunite may lose bookmarks and metadata")
+		err = runCommand(project.Dir, "pdfunite", nil, append(files, merged)...)
+	} else {
+		err = fmt.Errorf("qpdf is required to merge pdf files")
+	}
+	if err != nil {
+		return err
+	}
+
+	return utils.CopyFile(merged, output)
+}
@@ -26,20 +26,20 @@ import (
 	"github.com/zivlakmilos/author/build"
 )
 
-type BuildConfig struct {
-	projectName string
-}
-
 var buildCmd = cobra.Command{
 	Use:   "build",
 	Short: "Build new project",
 	Run: func(cmd *cobra.Command, args []string) {
-		build.BuildProject()
+		build.BuildProject(buildCfg)
 	},
 }
 
-var buildCfg = BuildConfig{}
+var buildCfg = build.DefaultConfig()
 
 func init() {
 	rootCmd.AddCommand(&buildCmd)
+
+	buildCmd.Flags().BoolVar(&buildCfg.Production, "production", false, "minify and fingerprint html assets")
+	buildCmd.Flags().BoolVar(&buildCfg.KeepIntermediate, "keep-intermediate", false, "keep intermediate tex and log files")
+	buildCmd.Flags().BoolVar(&buildCfg.Draft, "draft", false, "build draft pdf with watermark, line numbers and review notes")
 }
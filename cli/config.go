@@ -0,0 +1,77 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/zivlakmilos/author/config"
+)
+
+var configCmd = cobra.Command{
+	Use:   "config",
+	Short: "Inspect and convert project file",
+}
+
+var configConvertCmd = cobra.Command{
+	Use:   "convert",
+	Short: "Convert project file to json, yaml or toml",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.ConvertProject(configConvertCfg)
+	},
+}
+
+var configConvertCfg = config.DefaultConvertConfig()
+
+var configShowCmd = cobra.Command{
+	Use:   "show",
+	Short: "Show project file or resolved configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.ShowProject(configShowCfg)
+	},
+}
+
+var configShowCfg = config.DefaultShowConfig()
+
+var configSchemaCmd = cobra.Command{
+	Use:   "schema",
+	Short: "Print JSON Schema of project file",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.PrintSchema(configSchemaCfg)
+	},
+}
+
+var configSchemaCfg = config.DefaultSchemaConfig()
+
+func init() {
+	rootCmd.AddCommand(&configCmd)
+	configCmd.AddCommand(&configConvertCmd)
+	configCmd.AddCommand(&configShowCmd)
+	configCmd.AddCommand(&configSchemaCmd)
+
+	configConvertCmd.Flags().StringVar(&configConvertCfg.To, "to", "", "target format (json, yaml, yml or toml)")
+	configConvertCmd.Flags().BoolVar(&configConvertCfg.Force, "force", false, "overwrite existing target file")
+	configConvertCmd.MarkFlagRequired("to")
+
+	configShowCmd.Flags().BoolVar(&configShowCfg.Resolved, "resolved", false, "show effective configuration with origin of each value")
+
+	configSchemaCmd.Flags().StringVarP(&configSchemaCfg.Output, "output", "o", "", "write schema to file instead of stdout")
+}
@@ -41,4 +41,6 @@ func init() {
 
 	createCmd.Flags().StringVarP(&createCfg.ProjectName, "name", "n", "", "project name")
 	createCmd.Flags().StringVarP(&createCfg.Template, "template", "t", "", "project template")
+	createCmd.Flags().StringArrayVar(&createCfg.Variables, "set", nil, "template variable as key=value")
+	createCmd.Flags().StringSliceVar(&createCfg.TemplateDirs, "template-dir", nil, "additional template directory, overrides templates with the same name")
 }
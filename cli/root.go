@@ -21,13 +21,45 @@ THE SOFTWARE.
 */
 package cli
 
-import "github.com/spf13/cobra"
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/zivlakmilos/author/data"
+)
 
 var rootCmd = &cobra.Command{
 	Use:     "author",
 	Short:   "author is command line tool for writing books and papers",
 	Version: "1.0.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if rootCfg.dir != "" {
+			err := os.Chdir(rootCfg.dir)
+			if err != nil {
+				return err
+			}
+		}
+
+		if rootCfg.project != "" {
+			project, err := filepath.Abs(rootCfg.project)
+			if err != nil {
+				return err
+			}
+
+			data.SetProjectPath(project)
+		}
+
+		return nil
+	},
+}
+
+var rootCfg struct {
+	project string
+	dir     string
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&rootCfg.project, "project", "", "path to project file or directory")
+	rootCmd.PersistentFlags().StringVarP(&rootCfg.dir, "directory", "C", "", "run as if author was started in this directory")
 }
@@ -41,4 +41,5 @@ func init() {
 
 	watchCmd.Flags().BoolVar(&watchCfg.Html, "html", false, "build html")
 	watchCmd.Flags().BoolVar(&watchCfg.Pdf, "pdf", false, "build pdf")
+	watchCmd.Flags().BoolVar(&watchCfg.Draft, "draft", false, "build draft pdf")
 }
@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+type ConvertConfig struct {
+	To    string
+	Force bool
+}
+
+func DefaultConvertConfig() ConvertConfig {
+	return ConvertConfig{
+		To:    "",
+		Force: false,
+	}
+}
+
+func ConvertProject(cfg ConvertConfig) {
+	dst, err := convertProject(cfg)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	utils.PrintSuccess("Project file converted to " + dst)
+}
+
+func convertProject(cfg ConvertConfig) (string, error) {
+	src, err := data.LocateProject()
+	if err != nil {
+		return "", err
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(cfg.To), ".")
+	dst := path.Join(path.Dir(src), "project."+ext)
+
+	_, err = data.ProjectFileFormat(dst)
+	if err != nil {
+		return "", fmt.Errorf("unsupported format '%s', use json, yaml, yml or toml", cfg.To)
+	}
+
+	if dst == src {
+		return "", fmt.Errorf("project file is already %s", dst)
+	}
+
+	if utils.FileExists(dst) && !cfg.Force {
+		return "", fmt.Errorf("%s already exists, use --force to overwrite it", dst)
+	}
+
+	values, err := data.ReadProjectValues(src)
+	if err != nil {
+		return "", err
+	}
+
+	losses, err := data.ProjectFileLosses(src, values)
+	if err != nil {
+		return "", err
+	}
+	if len(losses) > 0 {
+		return "", fmt.Errorf("converting %s would drop %s, remove them or convert it by hand",
+			path.Base(src), strings.Join(losses, ", "))
+	}
+
+	err = data.SaveProjectValues(dst, values)
+	if err != nil {
+		return "", err
+	}
+
+	backup := src + ".bak"
+	err = os.Rename(src, backup)
+	if err != nil {
+		return "", err
+	}
+	utils.PrintInfo("previous project file moved to " + backup)
+
+	return dst, nil
+}
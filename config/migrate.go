@@ -0,0 +1,143 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+type MigrateConfig struct {
+	DryRun bool
+}
+
+func DefaultMigrateConfig() MigrateConfig {
+	return MigrateConfig{
+		DryRun: false,
+	}
+}
+
+func MigrateProject(cfg MigrateConfig) {
+	err := migrateProject(cfg)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+}
+
+func migrateProject(cfg MigrateConfig) error {
+	file, err := data.LocateProject()
+	if err != nil {
+		return err
+	}
+
+	values, err := data.ReadProjectValues(file)
+	if err != nil {
+		return err
+	}
+
+	version := data.ProjectSchemaVersion(values)
+	applied, err := data.MigrateProjectValues(values)
+	if err != nil {
+		return err
+	}
+
+	if version == data.CurrentSchemaVersion {
+		utils.PrintSuccess(fmt.Sprintf("project is already at schema version %d", version))
+		return nil
+	}
+
+	before, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range applied {
+		utils.PrintInfo("migration " + m)
+	}
+
+	name := filepath.Base(file)
+
+	losses, err := data.ProjectFileLosses(file, values)
+	if err != nil {
+		return err
+	}
+	if len(losses) > 0 {
+		msg := fmt.Sprintf("migrating %s would drop %s", name, strings.Join(losses, ", "))
+		if !cfg.DryRun {
+			return fmt.Errorf("%s, remove them or apply migrations above by hand", msg)
+		}
+		utils.PrintWarning(msg)
+	}
+
+	if cfg.DryRun {
+		tmp, err := os.CreateTemp("", "author-migrate-*"+filepath.Ext(file))
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+
+		err = data.SaveProjectValues(tmp.Name(), values)
+		if err != nil {
+			return err
+		}
+
+		after, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(utils.LineDiff(name, name, string(before), string(after)))
+		return nil
+	}
+
+	backup := file + ".bak"
+	err = os.WriteFile(backup, before, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = data.SaveProjectValues(file, values)
+	if err != nil {
+		return err
+	}
+
+	after, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(utils.LineDiff(filepath.Base(backup), name, string(before), string(after)))
+
+	if _, ok := values["extends"]; ok {
+		utils.PrintWarning("base project files are not migrated, run migrate in their directories")
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("project migrated from schema version %d to %d", version, data.CurrentSchemaVersion))
+
+	return nil
+}
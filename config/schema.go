@@ -0,0 +1,59 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+type SchemaConfig struct {
+	Output string
+}
+
+func DefaultSchemaConfig() SchemaConfig {
+	return SchemaConfig{
+		Output: "",
+	}
+}
+
+func PrintSchema(cfg SchemaConfig) {
+	schema, err := data.ProjectSchema()
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	if cfg.Output == "" {
+		fmt.Println(string(schema))
+		return
+	}
+
+	err = os.WriteFile(cfg.Output, append(schema, '\n'), 0644)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	utils.PrintSuccess("Schema written to " + cfg.Output)
+}
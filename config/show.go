@@ -0,0 +1,94 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+type ShowConfig struct {
+	Resolved bool
+}
+
+func DefaultShowConfig() ShowConfig {
+	return ShowConfig{
+		Resolved: false,
+	}
+}
+
+func ShowProject(cfg ShowConfig) {
+	err := showProject(cfg)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+}
+
+func showProject(cfg ShowConfig) error {
+	file, err := data.LocateProject()
+	if err != nil {
+		return err
+	}
+
+	if !cfg.Resolved {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(content))
+		return nil
+	}
+
+	values, err := data.ResolveProjectValues(file)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(file)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, val := range values {
+		content, err := json.Marshal(val.Value)
+		if err != nil {
+			return err
+		}
+
+		var origins []string
+		for _, origin := range val.Origins {
+			if rel, err := filepath.Rel(dir, origin); err == nil && !strings.HasPrefix(rel, "..") {
+				origin = rel
+			}
+			origins = append(origins, origin)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", val.Key, content, strings.Join(origins, ", "))
+	}
+
+	return w.Flush()
+}
@@ -24,14 +24,18 @@ package create
 import "github.com/zivlakmilos/author/utils"
 
 type Config struct {
-	ProjectName string
-	Template    string
+	ProjectName  string
+	Template     string
+	TemplateDirs []string
+	Variables    []string
 }
 
 func DefaultConfig() Config {
 	return Config{
-		ProjectName: "",
-		Template:    "",
+		ProjectName:  "",
+		Template:     "",
+		TemplateDirs: nil,
+		Variables:    nil,
 	}
 }
 
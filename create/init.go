@@ -0,0 +1,340 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+const defaultInitTemplate = "book"
+
+var initSkipFolders = []string{"build", "node_modules", templateFolder}
+
+var initAssetFolders = []string{"assets", "figures", "images", "img", "media", "pictures", "static"}
+
+var initSkipDocuments = []string{"changelog", "contributing", "license", "readme"}
+
+type InitConfig struct {
+	Template     string
+	TemplateDirs []string
+	Variables    []string
+	Yes          bool
+}
+
+func DefaultInitConfig() InitConfig {
+	return InitConfig{
+		Template:     "",
+		TemplateDirs: nil,
+		Variables:    nil,
+		Yes:          false,
+	}
+}
+
+// initScan is what init guessed from existing files.
+type initScan struct {
+	sources        []string
+	bibliographies []string
+	assets         []string
+}
+
+func (s initScan) bibliography() string {
+	if len(s.bibliographies) == 0 {
+		return ""
+	}
+
+	return s.bibliographies[0]
+}
+
+func InitProject(cfg InitConfig) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	if file, err := data.FindProjectFile(cwd); err == nil {
+		utils.ExitWithError(fmt.Errorf("project already exists: %s", file))
+	}
+
+	scan, err := scanProjectFolder(cwd)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	if !cfg.Yes {
+		showInitTUI(cfg, scan)
+		return
+	}
+
+	if cfg.Template == "" {
+		cfg.Template = defaultInitTemplate
+	}
+
+	skipped, err := initProject(cfg, scan)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	printInitSkipped(skipped)
+	utils.PrintSuccess("Project initialized")
+}
+
+func printInitSkipped(skipped []string) {
+	for _, name := range skipped {
+		utils.PrintWarning(fmt.Sprintf("skipped existing %s", name))
+	}
+}
+
+func scanProjectFolder(dir string) (initScan, error) {
+	var scan initScan
+
+	fsys := os.DirFS(dir)
+	err := fs.WalkDir(fsys, ".", func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if pth == "." {
+			return nil
+		}
+
+		name := d.Name()
+		if strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if !strings.Contains(pth, "/") && slices.Contains(initSkipFolders, name) {
+				return fs.SkipDir
+			}
+			if slices.Contains(initAssetFolders, strings.ToLower(name)) {
+				scan.assets = append(scan.assets, pth)
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		switch strings.ToLower(path.Ext(name)) {
+		case ".md", ".markdown":
+			base := strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))
+			if !strings.Contains(pth, "/") && slices.Contains(initSkipDocuments, base) {
+				return nil
+			}
+			scan.sources = append(scan.sources, pth)
+		case ".bib":
+			scan.bibliographies = append(scan.bibliographies, pth)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return scan, err
+	}
+
+	for _, files := range [][]string{scan.sources, scan.bibliographies, scan.assets} {
+		slices.SortFunc(files, naturalCompare)
+	}
+
+	if len(scan.sources) == 0 {
+		return scan, errors.New("no markdown files found")
+	}
+
+	return scan, nil
+}
+
+func naturalCompare(a, b string) int {
+	if utils.NaturalLess(a, b) {
+		return -1
+	}
+	if utils.NaturalLess(b, a) {
+		return 1
+	}
+	return 0
+}
+
+func initProject(cfg InitConfig, scan initScan) ([]string, error) {
+	t, cleanup, err := loadTemplate(cfg.Template, cfg.TemplateDirs)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return initProjectFromTemplate(cfg, t, scan)
+}
+
+// initManifest returns template manifest where title defaults to name of
+// project folder.
+func initManifest(t template, dir string) templateManifest {
+	manifest := t.manifest
+	manifest.Variables = slices.Clone(manifest.Variables)
+
+	for i, v := range manifest.Variables {
+		if v.Name == "title" {
+			manifest.Variables[i].Default = path.Base(dir)
+		}
+	}
+
+	return manifest
+}
+
+// initProjectFromTemplate writes template files missing in project folder
+// and returns names of files which already existed.
+func initProjectFromTemplate(cfg InitConfig, t template, scan initScan) ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := parseVariables(cfg.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := initManifest(t, cwd).resolveVariables(values)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := renderTemplate(t, "", vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []string
+
+	projectFile := ""
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if slices.Contains(data.ProjectFileNames, name) {
+			if projectFile == "" {
+				projectFile = name
+			}
+			continue
+		}
+
+		if strings.HasPrefix(name, "src/") {
+			continue
+		}
+
+		target := path.Join(cwd, name)
+		if utils.FileExists(target) {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		err = writeMergeResult(target, files[name], false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if projectFile == "" {
+		return nil, fmt.Errorf("template '%s' has no project file", t.name)
+	}
+
+	err = writeInitProjectFile(path.Join(cwd, projectFile), files[projectFile], scan)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.FileExists(path.Join(cwd, data.SchemaFileName)) {
+		schema, err := data.ProjectSchema()
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.WriteFile(path.Join(cwd, data.SchemaFileName), append(schema, '\n'), 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	base := map[string][]byte{}
+	for name, content := range files {
+		if strings.HasPrefix(name, templateFolder+"/") {
+			base[name] = content
+		}
+	}
+
+	err = writeTemplateBase(cwd, base)
+	if err != nil {
+		return nil, err
+	}
+
+	return skipped, writeTemplateRecord(cwd, t, vars)
+}
+
+// writeInitProjectFile writes template project file with sources,
+// bibliography and assets found in project folder.
+func writeInitProjectFile(file string, content []byte, scan initScan) error {
+	err := os.WriteFile(file, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	values, err := data.ReadProjectValues(file)
+	if err != nil {
+		return err
+	}
+
+	values["sources"] = scan.sources
+
+	if bib := scan.bibliography(); bib != "" {
+		values["bibliography"] = bib
+	} else {
+		delete(values, "bibliography")
+		delete(values, "biblatex")
+	}
+
+	var assets []string
+	if list, ok := values["assets"].([]any); ok {
+		for _, a := range list {
+			if s, ok := a.(string); ok && utils.FileExists(path.Join(path.Dir(file), s)) {
+				assets = append(assets, s)
+			}
+		}
+	}
+	for _, a := range scan.assets {
+		if !slices.Contains(assets, a) {
+			assets = append(assets, a)
+		}
+	}
+
+	if len(assets) > 0 {
+		values["assets"] = assets
+	} else {
+		delete(values, "assets")
+	}
+
+	return data.SaveProjectValues(file, values)
+}
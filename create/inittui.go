@@ -0,0 +1,243 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zivlakmilos/author/utils"
+)
+
+type initProjectModel struct {
+	cfg         InitConfig
+	scan        initScan
+	lstTemplate list.Model
+	wizard      variableWizard
+	template    *template
+	cleanup     func()
+	selected    int
+	skipped     []string
+	done        bool
+	err         error
+}
+
+func showInitTUI(cfg InitConfig, scan initScan) {
+	lstTemplate := list.New(getTemplatesList(cfg.TemplateDirs), list.NewDefaultDelegate(), 0, 0)
+	lstTemplate.Title = "Template"
+
+	m := initProjectModel{
+		cfg:         cfg,
+		scan:        scan,
+		lstTemplate: lstTemplate,
+		wizard:      newVariableWizard(),
+	}
+
+	if cfg.Template != "" {
+		m.err = m.loadTemplate()
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	res, err := p.Run()
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+
+	if m, ok := res.(initProjectModel); ok {
+		if m.cleanup != nil {
+			m.cleanup()
+		}
+
+		if m.err != nil {
+			utils.ExitWithError(m.err)
+		}
+
+		if m.done {
+			printInitSkipped(m.skipped)
+			utils.PrintSuccess("Project initialized")
+		}
+	}
+}
+
+func (m *initProjectModel) loadTemplate() error {
+	t, cleanup, err := loadTemplate(m.cfg.Template, m.cfg.TemplateDirs)
+	if err != nil {
+		return err
+	}
+	m.template = &t
+	m.cleanup = cleanup
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	return m.wizard.start(initManifest(t, cwd).Variables, m.cfg.Variables)
+}
+
+func (m initProjectModel) Init() tea.Cmd {
+	if m.err != nil {
+		err := m.err
+		return func() tea.Msg { return quitWithErrorMsg{err: err} }
+	}
+
+	return nil
+}
+
+func (m initProjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.cfg.Template == "" {
+			switch msg.String() {
+			case "ctrl+c", "esc", "q":
+				return m, tea.Quit
+			case "enter":
+				i, ok := m.lstTemplate.SelectedItem().(item)
+				if !ok {
+					return m, tea.Quit
+				}
+
+				m.cfg.Template = i.key
+				err := m.loadTemplate()
+				if err != nil {
+					return m, func() tea.Msg { return quitWithErrorMsg{err: err} }
+				}
+				return m, nil
+			}
+			break
+		}
+
+		if m.wizard.active() {
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				return m, tea.Quit
+			case "enter":
+				if set, ok := m.wizard.submit(); ok {
+					m.cfg.Variables = append(m.cfg.Variables, set)
+				}
+				return m, nil
+			}
+			return m, m.wizard.update(msg)
+		}
+
+		sources := m.scan.sources
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+		case "up", "k":
+			m.selected = max(m.selected-1, 0)
+		case "down", "j":
+			m.selected = min(m.selected+1, len(sources)-1)
+		case "shift+up", "K":
+			if m.selected > 0 {
+				sources[m.selected-1], sources[m.selected] = sources[m.selected], sources[m.selected-1]
+				m.selected--
+			}
+		case "shift+down", "J":
+			if m.selected < len(sources)-1 {
+				sources[m.selected+1], sources[m.selected] = sources[m.selected], sources[m.selected+1]
+				m.selected++
+			}
+		case "enter", "y":
+			return m, m.initProjectCmd
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.lstTemplate.SetSize(msg.Width, msg.Height)
+	case quitWithErrorMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	case initDoneMsg:
+		m.skipped = msg.skipped
+		m.done = true
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd = nil
+	if m.cfg.Template == "" {
+		m.lstTemplate, cmd = m.lstTemplate.Update(msg)
+	} else if m.wizard.active() {
+		cmd = m.wizard.update(msg)
+	}
+
+	return m, cmd
+}
+
+func (m initProjectModel) View() string {
+	if m.cfg.Template == "" {
+		return m.lstTemplate.View()
+	}
+
+	if m.wizard.active() {
+		return m.wizard.view()
+	}
+
+	var b strings.Builder
+
+	cwd, _ := os.Getwd()
+	fmt.Fprintf(&b, "Initialize project in %s\n\n", cwd)
+	fmt.Fprintf(&b, "Template: %s\n\n", m.cfg.Template)
+
+	b.WriteString("Sources:\n")
+	for i, src := range m.scan.sources {
+		cursor := " "
+		if i == m.selected {
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %2d. %s\n", cursor, i+1, src)
+	}
+
+	bib := m.scan.bibliography()
+	if bib == "" {
+		bib = "none"
+	}
+	fmt.Fprintf(&b, "\nBibliography: %s\n", bib)
+	if len(m.scan.bibliographies) > 1 {
+		fmt.Fprintf(&b, "  (ignored: %s)\n", strings.Join(m.scan.bibliographies[1:], ", "))
+	}
+
+	assets := strings.Join(m.scan.assets, ", ")
+	if assets == "" {
+		assets = "none"
+	}
+	fmt.Fprintf(&b, "Assets: %s\n\n", assets)
+
+	b.WriteString("(j/k to select, J/K to move source, enter to confirm, esc to quit)")
+
+	return b.String()
+}
+
+type initDoneMsg struct {
+	skipped []string
+}
+
+func (m initProjectModel) initProjectCmd() tea.Msg {
+	skipped, err := initProjectFromTemplate(m.cfg, *m.template, m.scan)
+	if err != nil {
+		return quitWithErrorMsg{err: err}
+	}
+
+	return initDoneMsg{skipped: skipped}
+}
@@ -23,10 +23,10 @@ package create
 
 import (
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/zivlakmilos/author/efs"
 )
 
 type item struct {
+	key   string
 	title string
 	desc  string
 }
@@ -40,21 +40,22 @@ func (i item) Description() string {
 }
 
 func (i item) FilterValue() string {
-	return i.title
+	return i.title + " " + i.key
 }
 
-func getTemplatesList() []list.Item {
+func getTemplatesList(templateDirs []string) []list.Item {
 	var items []list.Item
 
-	files, err := efs.Templates.ReadDir("templates")
+	templates, err := discoverTemplates(templateDirs)
 	if err != nil {
 		return nil
 	}
 
-	for _, f := range files {
+	for _, t := range templates {
 		items = append(items, item{
-			title: f.Name(),
-			desc:  "template",
+			key:   t.name,
+			title: t.displayName(),
+			desc:  t.summary(),
 		})
 	}
 
@@ -22,35 +22,169 @@ THE SOFTWARE.
 package create
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"strings"
 
-	efs "github.com/zivlakmilos/author/efs"
-	"github.com/zivlakmilos/author/utils"
+	"github.com/zivlakmilos/author/data"
 )
 
 func createProject(cfg Config) error {
+	t, cleanup, err := loadTemplate(cfg.Template, cfg.TemplateDirs)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return createProjectFromTemplate(cfg, t)
+}
+
+// createProjectFromTemplate creates project from already loaded template, so
+// remote template is fetched only once.
+func createProjectFromTemplate(cfg Config, t template) error {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
 	dst := path.Join(cwd, cfg.ProjectName)
-	src := path.Join("templates", cfg.Template)
 
 	if files, _ := os.ReadDir(dst); len(files) > 0 {
 		return fmt.Errorf("directory %s not empty", dst)
 	}
 
-	if !utils.IsDirExists(efs.Templates, src) {
-		return fmt.Errorf("template not found")
+	values, err := parseVariables(cfg.Variables)
+	if err != nil {
+		return err
 	}
 
-	err = utils.CopyDir(efs.Templates, src, dst)
+	vars, err := t.manifest.resolveVariables(values)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = copyTemplate(t, dst, vars)
+	if err != nil {
+		return err
+	}
+
+	schema, err := data.ProjectSchema()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path.Join(dst, data.SchemaFileName), append(schema, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+
+	base, err := renderTemplate(t, templateFolder, vars)
+	if err != nil {
+		return err
+	}
+
+	err = writeTemplateBase(dst, base)
+	if err != nil {
+		return err
+	}
+
+	return writeTemplateRecord(dst, t, vars)
+}
+
+func copyTemplate(t template, dst string, vars map[string]string) error {
+	return fs.WalkDir(t.fsys, t.root, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := t.relPath(pth)
+		if rel == manifestFileName {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+
+		target := path.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+
+		content, err := renderTemplateFile(t, pth, vars)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, content, 0644)
+	})
+}
+
+// renderTemplate returns files of template folder inside template with
+// substituted variables, keyed by path relative to template root.
+func renderTemplate(t template, folder string, vars map[string]string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	root := path.Join(t.root, folder)
+	if _, err := fs.Stat(t.fsys, root); errors.Is(err, fs.ErrNotExist) {
+		return files, nil
+	}
+
+	err := fs.WalkDir(t.fsys, root, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		rel := t.relPath(pth)
+		if rel == manifestFileName {
+			return nil
+		}
+
+		content, err := renderTemplateFile(t, pth, vars)
+		if err != nil {
+			return err
+		}
+
+		files[rel] = content
+		return nil
+	})
+
+	return files, err
+}
+
+func renderTemplateFile(t template, pth string, vars map[string]string) ([]byte, error) {
+	content, err := fs.ReadFile(t.fsys, pth)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTextFile(pth) {
+		content = substituteVariables(content, path.Ext(pth), vars)
+	}
+
+	return content, nil
+}
+
+func parseVariables(set []string) (map[string]string, error) {
+	values := map[string]string{}
+
+	for _, item := range set {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid variable '%s', use key=value", item)
+		}
+
+		values[key] = val
+	}
+
+	return values, nil
 }
@@ -0,0 +1,308 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const manifestFileName = "template.json"
+
+var rePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w-]*)\s*\}\}`)
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`^`, `\^{}`,
+	`~`, `\~{}`,
+)
+
+type templateVariable struct {
+	Name     string   `json:"name"`
+	Prompt   string   `json:"prompt,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+type templateManifest struct {
+	Name        string             `json:"name,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Targets     []string           `json:"targets,omitempty"`
+	Variables   []templateVariable `json:"variables,omitempty"`
+}
+
+func loadManifest(fsys fs.FS, root string) (templateManifest, error) {
+	var manifest templateManifest
+
+	content, err := fs.ReadFile(fsys, path.Join(root, manifestFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return manifest, nil
+		}
+		return manifest, err
+	}
+
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return manifest, fmt.Errorf("%s: %v", manifestFileName, err)
+	}
+
+	for _, v := range manifest.Variables {
+		if v.Pattern == "" {
+			continue
+		}
+
+		_, err := regexp.Compile(v.Pattern)
+		if err != nil {
+			return manifest, fmt.Errorf("%s: variable '%s': %v", manifestFileName, v.Name, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+func (v templateVariable) label() string {
+	if v.Prompt != "" {
+		return v.Prompt
+	}
+
+	return v.Name
+}
+
+func (v templateVariable) validate(val string) error {
+	if val == "" {
+		if v.Required {
+			return fmt.Errorf("%s is required", v.label())
+		}
+		return nil
+	}
+
+	if len(v.Options) > 0 && !slices.Contains(v.Options, val) {
+		return fmt.Errorf("%s must be one of %s", v.label(), strings.Join(v.Options, ", "))
+	}
+
+	if v.Pattern != "" && !regexp.MustCompile(v.Pattern).MatchString(val) {
+		return fmt.Errorf("%s must match %s", v.label(), v.Pattern)
+	}
+
+	return nil
+}
+
+func (m templateManifest) resolveVariables(values map[string]string) (map[string]string, error) {
+	vars := map[string]string{}
+
+	for key := range values {
+		if !slices.ContainsFunc(m.Variables, func(v templateVariable) bool { return v.Name == key }) {
+			return nil, fmt.Errorf("unknown template variable '%s'", key)
+		}
+	}
+
+	for _, v := range m.Variables {
+		val, ok := values[v.Name]
+		if !ok {
+			val = v.Default
+		}
+
+		err := v.validate(val)
+		if err != nil {
+			return nil, err
+		}
+
+		vars[v.Name] = val
+	}
+
+	return vars, nil
+}
+
+// substituteVariables replaces placeholders with values escaped for the
+// place they appear in: inside quoted strings of data files and scripts,
+// front matter of markdown, LaTeX or html. Markdown body gets raw values.
+func substituteVariables(content []byte, ext string, vars map[string]string) []byte {
+	switch strings.ToLower(ext) {
+	case ".json", ".js":
+		return substituteQuoted(content, vars, escapeScriptString)
+	case ".yaml", ".yml":
+		return substituteQuoted(content, vars, escapeYamlString)
+	case ".toml":
+		return substituteQuoted(content, vars, escapeTomlString)
+	case ".md":
+		front, body := splitFrontMatter(content)
+		front = substituteQuoted(front, vars, escapeYamlString)
+		body = substitutePlain(body, vars, func(s string) string { return s })
+		return append(front, body...)
+	case ".tex":
+		return substitutePlain(content, vars, latexEscaper.Replace)
+	case ".html", ".htm", ".xml":
+		return substitutePlain(content, vars, html.EscapeString)
+	}
+
+	return substitutePlain(content, vars, func(s string) string { return s })
+}
+
+func substitutePlain(content []byte, vars map[string]string, escape func(string) string) []byte {
+	return rePlaceholder.ReplaceAllFunc(content, func(match []byte) []byte {
+		name := string(rePlaceholder.FindSubmatch(match)[1])
+		val, ok := vars[name]
+		if !ok {
+			return match
+		}
+
+		return []byte(escape(val))
+	})
+}
+
+// substituteQuoted escapes values by quote which is open at placeholder on
+// its line. Values outside of quoted strings are not escaped.
+func substituteQuoted(content []byte, vars map[string]string, escape func(val string, quote byte) string) []byte {
+	var b bytes.Buffer
+
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	for _, line := range lines {
+		last := 0
+		for _, loc := range rePlaceholder.FindAllSubmatchIndex(line, -1) {
+			val, ok := vars[string(line[loc[2]:loc[3]])]
+			if !ok {
+				continue
+			}
+
+			b.Write(line[last:loc[0]])
+			b.WriteString(escape(val, openQuote(line[:loc[0]])))
+			last = loc[1]
+		}
+		b.Write(line[last:])
+	}
+
+	return b.Bytes()
+}
+
+// openQuote returns quote character left open at the end of prefix, or 0.
+func openQuote(prefix []byte) byte {
+	var quote byte
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		switch {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote != 0 && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+
+	return quote
+}
+
+// splitFrontMatter splits markdown into yaml front matter block (with its
+// delimiters) and body.
+func splitFrontMatter(content []byte) ([]byte, []byte) {
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		return nil, content
+	}
+
+	offset := bytes.IndexByte(content, '\n') + 1
+	for offset < len(content) {
+		end := bytes.IndexByte(content[offset:], '\n')
+		if end < 0 {
+			end = len(content) - offset
+		} else {
+			end++
+		}
+
+		line := bytes.TrimRight(content[offset:offset+end], "\r\n")
+		offset += end
+		if string(line) == "---" || string(line) == "..." {
+			return content[:offset:offset], content[offset:]
+		}
+	}
+
+	return nil, content
+}
+
+func escapeScriptString(val string, quote byte) string {
+	switch quote {
+	case '"':
+		return escapeJsonString(val)
+	case '\'':
+		return strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(val)
+	}
+
+	return val
+}
+
+func escapeYamlString(val string, quote byte) string {
+	switch quote {
+	case '"':
+		return escapeJsonString(val)
+	case '\'':
+		return strings.ReplaceAll(val, "'", "''")
+	}
+
+	return val
+}
+
+// escapeTomlString escapes basic strings, literal strings can not hold
+// escapes and get raw value.
+func escapeTomlString(val string, quote byte) string {
+	if quote == '"' {
+		return escapeJsonString(val)
+	}
+
+	return val
+}
+
+func escapeJsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+
+	content := strings.TrimSpace(b.String())
+	return content[1 : len(content)-1]
+}
+
+func isTextFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json", ".yaml", ".yml", ".toml", ".md", ".js", ".tex", ".html", ".htm", ".xml", ".css", ".bib", ".txt":
+		return true
+	}
+
+	return false
+}
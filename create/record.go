@@ -0,0 +1,217 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+)
+
+const (
+	projectStateFolder = ".author"
+	templateRecordFile = "template.json"
+	templateBaseFolder = "template-base"
+	templateHashesFile = "binary-hashes.json"
+	templateFolder     = "template"
+)
+
+// templateBase is template as it was when project was created or last
+// updated. Text files are kept for three-way merge, binary files only by
+// hash, because they can only be compared.
+type templateBase struct {
+	files  map[string][]byte
+	hashes map[string]string
+}
+
+// templateRecord describes template project was created from.
+type templateRecord struct {
+	Name      string            `json:"name"`
+	Source    string            `json:"source"`
+	Revision  string            `json:"revision"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+func writeTemplateRecord(dst string, t template, vars map[string]string) error {
+	revision := t.revision
+	if revision == "" {
+		var err error
+		revision, err = treeHash(t.fsys, t.root)
+		if err != nil {
+			return err
+		}
+	}
+
+	record := templateRecord{
+		Name:      t.name,
+		Source:    t.source(),
+		Revision:  revision,
+		Variables: vars,
+	}
+
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := path.Join(dst, projectStateFolder)
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(dir, templateRecordFile), append(content, '\n'), 0644)
+}
+
+func readTemplateRecord(dir string) (templateRecord, error) {
+	var record templateRecord
+
+	content, err := os.ReadFile(path.Join(dir, projectStateFolder, templateRecordFile))
+	if err != nil {
+		return record, err
+	}
+
+	err = json.Unmarshal(content, &record)
+	return record, err
+}
+
+// writeTemplateBase saves text template files and hashes of binary ones, used
+// as base for three-way merge.
+func writeTemplateBase(dst string, files map[string][]byte) error {
+	dir := path.Join(dst, projectStateFolder, templateBaseFolder)
+
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	hashes := map[string]string{}
+	for rel, content := range files {
+		if !isTextFile(rel) {
+			hashes[rel] = contentHash(content)
+			continue
+		}
+
+		target := path.Join(dir, rel)
+
+		err = os.MkdirAll(path.Dir(target), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(target, content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(dir, templateHashesFile), append(content, '\n'), 0644)
+}
+
+func readTemplateBase(dst string) (templateBase, error) {
+	dir := path.Join(dst, projectStateFolder, templateBaseFolder)
+	base := templateBase{hashes: map[string]string{}}
+
+	var err error
+	base.files, err = readTemplateFiles(dir, templateFolder)
+	if err != nil {
+		return base, err
+	}
+
+	content, err := os.ReadFile(path.Join(dir, templateHashesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return base, nil
+	} else if err != nil {
+		return base, err
+	}
+
+	err = json.Unmarshal(content, &base.hashes)
+	return base, err
+}
+
+func (b templateBase) empty() bool {
+	return len(b.files) == 0 && len(b.hashes) == 0
+}
+
+func (b templateBase) has(name string) bool {
+	if _, ok := b.files[name]; ok {
+		return true
+	}
+
+	_, ok := b.hashes[name]
+	return ok
+}
+
+// equal reports whether content is the same as base version of file.
+func (b templateBase) equal(name string, content []byte) bool {
+	if hash, ok := b.hashes[name]; ok {
+		return hash == contentHash(content)
+	}
+
+	base, ok := b.files[name]
+	return ok && bytes.Equal(base, content)
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func readTemplateFiles(dir, folder string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	fsys := os.DirFS(dir)
+	if _, err := fs.Stat(fsys, folder); errors.Is(err, fs.ErrNotExist) {
+		return files, nil
+	}
+
+	err := fs.WalkDir(fsys, folder, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, pth)
+		if err != nil {
+			return err
+		}
+
+		files[pth] = content
+		return nil
+	})
+
+	return files, err
+}
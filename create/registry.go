@@ -0,0 +1,202 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/efs"
+)
+
+const embeddedOrigin = "embedded"
+
+type template struct {
+	name     string
+	origin   string
+	fsys     fs.FS
+	root     string
+	revision string
+	fetched  bool
+	manifest templateManifest
+}
+
+func userTemplatesDir() string {
+	dir, err := data.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return path.Join(dir, "templates")
+}
+
+func discoverTemplates(templateDirs []string) ([]template, error) {
+	templates := map[string]template{}
+
+	files, err := efs.Templates.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+
+		templates[f.Name()] = template{
+			name:   f.Name(),
+			origin: embeddedOrigin,
+			fsys:   efs.Templates,
+			root:   path.Join("templates", f.Name()),
+		}
+	}
+
+	if userDir := userTemplatesDir(); userDir != "" {
+		err = addLocalTemplates(templates, userDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	for _, dir := range templateDirs {
+		err = addLocalTemplates(templates, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []template
+	for _, t := range templates {
+		manifest, err := loadManifest(t.fsys, t.root)
+		if err != nil {
+			return nil, fmt.Errorf("template '%s': %v", t.name, err)
+		}
+		t.manifest = manifest
+
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+
+	return result, nil
+}
+
+func addLocalTemplates(templates map[string]template, dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+
+		templates[f.Name()] = template{
+			name:   f.Name(),
+			origin: dir,
+			fsys:   os.DirFS(dir),
+			root:   f.Name(),
+		}
+	}
+
+	return nil
+}
+
+func findTemplate(name string, templateDirs []string) (template, error) {
+	templates, err := discoverTemplates(templateDirs)
+	if err != nil {
+		return template{}, err
+	}
+
+	for _, t := range templates {
+		if t.name == name {
+			return t, nil
+		}
+	}
+
+	return template{}, fmt.Errorf("template '%s' not found", name)
+}
+
+// loadTemplate finds template by name or fetches it when name is git
+// repository, archive or folder path.
+func loadTemplate(name string, templateDirs []string) (template, func(), error) {
+	if isTemplateSource(name) {
+		return fetchTemplate(name)
+	}
+
+	t, err := findTemplate(name, templateDirs)
+	return t, func() {}, err
+}
+
+// relPath returns path of template file relative to template root.
+func (t template) relPath(pth string) string {
+	if t.root == "." {
+		if pth == "." {
+			return ""
+		}
+		return pth
+	}
+
+	return strings.TrimPrefix(strings.TrimPrefix(pth, t.root), "/")
+}
+
+// source returns where template comes from, so it can be found again.
+func (t template) source() string {
+	if t.origin == embeddedOrigin || t.fetched {
+		return t.origin
+	}
+
+	return path.Join(t.origin, t.name)
+}
+
+func (t template) displayName() string {
+	if t.manifest.Name != "" {
+		return t.manifest.Name
+	}
+
+	return t.name
+}
+
+func (t template) summary() string {
+	var parts []string
+
+	if t.manifest.Description != "" {
+		parts = append(parts, t.manifest.Description)
+	}
+	if len(t.manifest.Targets) > 0 {
+		parts = append(parts, strings.Join(t.manifest.Targets, ", "))
+	}
+	if len(t.manifest.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(t.manifest.Tags, " #"))
+	}
+	parts = append(parts, t.origin)
+
+	return strings.Join(parts, " · ")
+}
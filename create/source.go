@@ -0,0 +1,393 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const fileUrlPrefix = "file://"
+
+var archiveExtensions = []string{".tar.gz", ".tgz", ".zip"}
+
+func isTemplateSource(name string) bool {
+	if strings.HasPrefix(name, fileUrlPrefix) {
+		return true
+	}
+
+	if archiveExtension(name) != "" {
+		return true
+	}
+
+	if !strings.ContainsRune(name, '/') && !strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	info, err := os.Stat(splitRef(name))
+	return err == nil && info.IsDir()
+}
+
+func archiveExtension(name string) string {
+	lower := strings.ToLower(splitRef(name))
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+
+	return ""
+}
+
+func splitRef(source string) string {
+	src, _, _ := strings.Cut(source, "#")
+	return src
+}
+
+// fetchTemplate makes template from git repository, archive or folder.
+// Returned cleanup function removes temporary files and must be called when
+// template is no longer needed.
+func fetchTemplate(source string) (template, func(), error) {
+	cleanup := func() {}
+
+	src, ref, _ := strings.Cut(source, "#")
+	isUrl := strings.HasPrefix(src, fileUrlPrefix)
+
+	local := strings.TrimPrefix(src, fileUrlPrefix)
+	abs, err := filepath.Abs(local)
+	if err != nil {
+		return template{}, cleanup, err
+	}
+
+	if !isUrl {
+		src = abs
+	}
+
+	t := template{
+		name:    templateSourceName(abs),
+		origin:  src,
+		fetched: true,
+	}
+	if ref != "" {
+		t.origin += "#" + ref
+	}
+
+	tmp, err := os.MkdirTemp("", "author-template-*")
+	if err != nil {
+		return template{}, cleanup, err
+	}
+	cleanup = func() {
+		os.RemoveAll(tmp)
+	}
+
+	switch ext := archiveExtension(abs); {
+	case ext == ".zip":
+		err = extractZip(abs, tmp)
+		if err == nil {
+			t.revision, err = fileHash(abs)
+		}
+	case ext != "":
+		err = extractTarGz(abs, tmp)
+		if err == nil {
+			t.revision, err = fileHash(abs)
+		}
+	case isUrl || isGitRepo(abs):
+		t.revision, err = cloneTemplate(src, ref, tmp)
+	default:
+		if ref != "" {
+			err = fmt.Errorf("template '%s' is not a git repository", source)
+			break
+		}
+		err = os.Remove(tmp)
+		tmp = abs
+		cleanup = func() {}
+	}
+	if err != nil {
+		cleanup()
+		return template{}, func() {}, err
+	}
+
+	t.fsys = os.DirFS(tmp)
+	t.root = templateArchiveRoot(tmp)
+
+	if t.revision == "" {
+		t.revision, err = treeHash(t.fsys, t.root)
+		if err != nil {
+			cleanup()
+			return template{}, func() {}, err
+		}
+	}
+
+	t.manifest, err = loadManifest(t.fsys, t.root)
+	if err != nil {
+		cleanup()
+		return template{}, func() {}, fmt.Errorf("template '%s': %v", source, err)
+	}
+
+	return t, cleanup, nil
+}
+
+func templateSourceName(src string) string {
+	name := path.Base(filepath.ToSlash(src))
+	if ext := archiveExtension(name); ext != "" {
+		name = name[:len(name)-len(ext)]
+	}
+
+	return strings.TrimSuffix(name, ".git")
+}
+
+func isGitRepo(dir string) bool {
+	_, err := os.Stat(path.Join(dir, ".git"))
+	if err == nil {
+		return true
+	}
+
+	_, err = os.Stat(path.Join(dir, "HEAD"))
+	return err == nil && strings.HasSuffix(dir, ".git")
+}
+
+func cloneTemplate(src, ref, dst string) (string, error) {
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+
+	if !strings.HasPrefix(src, fileUrlPrefix) {
+		src = fileUrlPrefix + src
+	}
+	args = append(args, src, dst)
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git clone failed: %s", strings.TrimSpace(string(out)))
+	}
+
+	out, err = exec.Command("git", "-C", dst, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed with error '%v'", err)
+	}
+
+	err = os.RemoveAll(path.Join(dst, ".git"))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// templateArchiveRoot returns folder inside extracted template which holds
+// template files. Archives made from folder have single top level folder.
+func templateArchiveRoot(dir string) string {
+	if _, err := os.Stat(path.Join(dir, manifestFileName)); err == nil {
+		return "."
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return "."
+	}
+
+	var entries []os.DirEntry
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), ".") {
+			entries = append(entries, f)
+		}
+	}
+
+	if len(entries) == 1 && entries[0].IsDir() {
+		return entries[0].Name()
+	}
+
+	return "."
+}
+
+// safeJoin joins archive entry name to dst, so that entry can not be written
+// outside of dst. Returns empty string for archive root.
+func safeJoin(dst, name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, `\`, "/"))
+	if name == "/" {
+		return ""
+	}
+
+	return path.Join(dst, name)
+}
+
+func extractZip(archive, dst string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		target := safeJoin(dst, f.Name)
+		if target == "" {
+			continue
+		}
+
+		if f.FileInfo().IsDir() {
+			err = os.MkdirAll(target, os.ModePerm)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		err = writeExtractedFile(target, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractTarGz(archive, dst string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := safeJoin(dst, hdr.Name)
+		if target == "" {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.ModePerm)
+		case tar.TypeReg:
+			err = writeExtractedFile(target, tr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeExtractedFile(target string, r io.Reader) error {
+	err := os.MkdirAll(path.Dir(target), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func fileHash(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// treeHash returns content hash of template files, used as revision for
+// templates which are not versioned.
+func treeHash(fsys fs.FS, root string) (string, error) {
+	var files []string
+
+	err := fs.WalkDir(fsys, root, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			files = append(files, pth)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		content, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%s %d\n", strings.TrimPrefix(f, root), len(content))
+		h.Write(content)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
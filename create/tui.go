@@ -34,6 +34,9 @@ type model struct {
 	cfg            Config
 	txtProjectName textinput.Model
 	lstTemplate    list.Model
+	wizard         variableWizard
+	template       *template
+	cleanup        func()
 	err            error
 }
 
@@ -49,6 +52,10 @@ func showTUI(cfg Config) {
 	}
 
 	if m, ok := m.(model); ok {
+		if m.cleanup != nil {
+			m.cleanup()
+		}
+
 		if m.cfg.ProjectName == "" || m.cfg.Template == "" {
 			return
 		}
@@ -65,20 +72,43 @@ func initModel(cfg Config) model {
 	txtProjectName.Placeholder = "name"
 	txtProjectName.Focus()
 
-	items := getTemplatesList()
+	items := getTemplatesList(cfg.TemplateDirs)
 
 	lstTemplate := list.New(items, list.NewDefaultDelegate(), 0, 0)
 	lstTemplate.Title = "Template"
 
-	return model{
+	m := model{
 		cfg:            cfg,
 		txtProjectName: txtProjectName,
 		lstTemplate:    lstTemplate,
+		wizard:         newVariableWizard(),
 		err:            nil,
 	}
+
+	if cfg.Template != "" {
+		m.err = m.loadVariables()
+	}
+
+	return m
+}
+
+func (m *model) loadVariables() error {
+	t, cleanup, err := loadTemplate(m.cfg.Template, m.cfg.TemplateDirs)
+	if err != nil {
+		return err
+	}
+	m.template = &t
+	m.cleanup = cleanup
+
+	return m.wizard.start(t.manifest.Variables, m.cfg.Variables)
 }
 
 func (m model) Init() tea.Cmd {
+	if m.err != nil {
+		err := m.err
+		return func() tea.Msg { return quitWithErrorMsg{err: err} }
+	}
+
 	if m.cfg.ProjectName == "" {
 		return textinput.Blink
 	}
@@ -90,9 +120,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q", "esc":
+		case "ctrl+c", "esc":
 			return m, tea.Quit
+		case "q":
+			if m.cfg.ProjectName != "" && m.cfg.Template == "" {
+				return m, tea.Quit
+			}
 		case "enter":
+			if m.err != nil {
+				return m, tea.Quit
+			}
+
 			if m.cfg.ProjectName == "" {
 				m.cfg.ProjectName = m.txtProjectName.Value()
 			} else if m.cfg.Template == "" {
@@ -101,10 +139,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, tea.Quit
 				}
 
-				m.cfg.Template = i.title
+				m.cfg.Template = i.key
+				err := m.loadVariables()
+				if err != nil {
+					return m, func() tea.Msg { return quitWithErrorMsg{err: err} }
+				}
+			} else if m.wizard.active() {
+				if set, ok := m.wizard.submit(); ok {
+					m.cfg.Variables = append(m.cfg.Variables, set)
+				}
 			}
 
-			if m.cfg.ProjectName == "" || m.cfg.Template == "" {
+			if m.cfg.ProjectName == "" || m.cfg.Template == "" || m.wizard.active() {
 				return m, nil
 			}
 
@@ -123,6 +169,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.txtProjectName, cmd = m.txtProjectName.Update(msg)
 	} else if m.cfg.Template == "" {
 		m.lstTemplate, cmd = m.lstTemplate.Update(msg)
+	} else if m.wizard.active() {
+		cmd = m.wizard.update(msg)
 	}
 
 	return m, cmd
@@ -141,11 +189,15 @@ func (m model) View() string {
 		return m.lstTemplate.View()
 	}
 
+	if m.wizard.active() {
+		return m.wizard.view()
+	}
+
 	return ""
 }
 
 func (m model) createProjectCmd() tea.Msg {
-	err := createProject(m.cfg)
+	err := createProjectFromTemplate(m.cfg, *m.template)
 	if err != nil {
 		return quitWithErrorMsg{err: err}
 	}
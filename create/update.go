@@ -0,0 +1,249 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/zivlakmilos/author/data"
+	"github.com/zivlakmilos/author/utils"
+)
+
+type UpdateConfig struct {
+	Template     string
+	TemplateDirs []string
+	DryRun       bool
+}
+
+func DefaultUpdateConfig() UpdateConfig {
+	return UpdateConfig{
+		Template:     "",
+		TemplateDirs: nil,
+		DryRun:       false,
+	}
+}
+
+func UpdateTemplate(cfg UpdateConfig) {
+	err := updateTemplate(cfg)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+}
+
+func updateTemplate(cfg UpdateConfig) error {
+	file, err := data.LocateProject()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(file)
+
+	record, err := readTemplateRecord(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	source := cfg.Template
+	if source == "" {
+		source = record.Source
+		if source == embeddedOrigin {
+			source = record.Name
+		}
+	}
+	if source == "" {
+		return fmt.Errorf("project has no template record, use --template to select template")
+	}
+
+	t, cleanup, err := loadTemplate(source, cfg.TemplateDirs)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	values := map[string]string{}
+	for _, v := range t.manifest.Variables {
+		if val, ok := record.Variables[v.Name]; ok {
+			values[v.Name] = val
+		}
+	}
+
+	vars, err := t.manifest.resolveVariables(values)
+	if err != nil {
+		return err
+	}
+
+	upstream, err := renderTemplate(t, templateFolder, vars)
+	if err != nil {
+		return err
+	}
+
+	base, err := readTemplateBase(dir)
+	if err != nil {
+		return err
+	}
+
+	local, err := readTemplateFiles(dir, templateFolder)
+	if err != nil {
+		return err
+	}
+
+	if base.empty() {
+		utils.PrintWarning("template base not found, customized files get template version in <file>.upstream")
+	}
+
+	names := slices.Sorted(maps.Keys(upstream))
+	for name := range base.files {
+		if _, ok := upstream[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range base.hashes {
+		if _, ok := upstream[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changes, conflicts := 0, 0
+	for _, name := range names {
+		res, err := mergeTemplateFile(dir, name, base, local, upstream, cfg.DryRun)
+		if err != nil {
+			return err
+		}
+
+		switch res {
+		case "":
+			continue
+		case mergeConflict:
+			conflicts++
+			utils.PrintWarning(fmt.Sprintf("%s %s", res, name))
+		default:
+			changes++
+			utils.PrintInfo(fmt.Sprintf("%s %s", res, name))
+		}
+	}
+
+	if cfg.DryRun {
+		utils.PrintInfo(fmt.Sprintf("%d files would change, %d conflicts", changes, conflicts))
+		return nil
+	}
+
+	err = writeTemplateBase(dir, upstream)
+	if err != nil {
+		return err
+	}
+
+	err = writeTemplateRecord(dir, t, vars)
+	if err != nil {
+		return err
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d files have conflicts, resolve conflict markers and compare .upstream files", conflicts)
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("template updated, %d files changed", changes))
+	return nil
+}
+
+const (
+	mergeAdded    = "added"
+	mergeUpdated  = "updated"
+	mergeRemoved  = "removed"
+	mergeMerged   = "merged"
+	mergeConflict = "conflict"
+)
+
+// mergeTemplateFile applies upstream change of single template file to
+// project and returns what was done, or empty string if nothing changed.
+// Without base version, or for binary files, template version is written
+// next to the file as <file>.upstream.
+func mergeTemplateFile(dir, name string, base templateBase, local, upstream map[string][]byte, dryRun bool) (string, error) {
+	hasBase := base.has(name)
+	l, hasLocal := local[name]
+	u, hasUpstream := upstream[name]
+
+	sameAsBase := func(x []byte, hasX bool) bool {
+		if hasX != hasBase {
+			return false
+		}
+		return !hasX || base.equal(name, x)
+	}
+
+	target := path.Join(dir, name)
+
+	if sameAsBase(u, hasUpstream) || (hasLocal == hasUpstream && bytes.Equal(l, u)) {
+		return "", nil
+	}
+
+	if sameAsBase(l, hasLocal) {
+		if !hasUpstream {
+			if dryRun {
+				return mergeRemoved, nil
+			}
+			return mergeRemoved, os.Remove(target)
+		}
+
+		res := mergeUpdated
+		if !hasLocal {
+			res = mergeAdded
+		}
+		return res, writeMergeResult(target, u, dryRun)
+	}
+
+	if !hasLocal || !hasUpstream {
+		return mergeConflict, nil
+	}
+
+	if !hasBase || !isTextFile(name) {
+		return mergeConflict, writeMergeResult(target+".upstream", u, dryRun)
+	}
+
+	merged, conflict := utils.Merge3(string(base.files[name]), string(l), string(u), "project", "template")
+
+	res := mergeMerged
+	if conflict {
+		res = mergeConflict
+	}
+
+	return res, writeMergeResult(target, []byte(merged), dryRun)
+}
+
+func writeMergeResult(target string, content []byte, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+
+	err := os.MkdirAll(path.Dir(target), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target, content, 0644)
+}
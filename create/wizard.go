@@ -0,0 +1,129 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package create
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// variableWizard asks for template variables which were not set with --set,
+// one at a time.
+type variableWizard struct {
+	txtVariable textinput.Model
+	variables   []templateVariable
+	err         error
+}
+
+func newVariableWizard() variableWizard {
+	return variableWizard{
+		txtVariable: textinput.New(),
+	}
+}
+
+// start queues variables which are not already set.
+func (w *variableWizard) start(variables []templateVariable, set []string) error {
+	values, err := parseVariables(set)
+	if err != nil {
+		return err
+	}
+
+	w.variables = nil
+	for _, v := range variables {
+		if _, ok := values[v.Name]; !ok {
+			w.variables = append(w.variables, v)
+		}
+	}
+
+	w.next()
+
+	return nil
+}
+
+func (w *variableWizard) active() bool {
+	return len(w.variables) > 0
+}
+
+func (w *variableWizard) next() {
+	w.txtVariable.Reset()
+	w.err = nil
+
+	if len(w.variables) == 0 {
+		return
+	}
+
+	w.txtVariable.Placeholder = w.variables[0].Default
+	w.txtVariable.Focus()
+}
+
+// submit validates current value and returns it as key=value, like --set.
+func (w *variableWizard) submit() (string, bool) {
+	v := w.variables[0]
+
+	val := w.txtVariable.Value()
+	if val == "" {
+		val = v.Default
+	}
+
+	err := v.validate(val)
+	if err != nil {
+		w.err = err
+		return "", false
+	}
+
+	w.variables = w.variables[1:]
+	w.next()
+
+	return v.Name + "=" + val, true
+}
+
+func (w *variableWizard) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	w.txtVariable, cmd = w.txtVariable.Update(msg)
+	return cmd
+}
+
+func (w variableWizard) view() string {
+	v := w.variables[0]
+
+	hint := ""
+	if len(v.Options) > 0 {
+		hint = fmt.Sprintf("(%s)\n\n", strings.Join(v.Options, ", "))
+	}
+
+	errMsg := ""
+	if w.err != nil {
+		errMsg = fmt.Sprintf("%s\n\n", w.err)
+	}
+
+	return fmt.Sprintf(
+		"%s\n\n%s\n\n%s%s%s",
+		v.label(),
+		w.txtVariable.View(),
+		hint,
+		errMsg,
+		"(esc to quit)",
+	)
+}
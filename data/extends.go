@@ -0,0 +1,352 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const appendSuffix = "+"
+
+type ProjectValue struct {
+	Key     string
+	Value   any
+	Origins []string
+}
+
+func UserConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, "author"), nil
+}
+
+func ResolveProjectValues(filePath string) ([]ProjectValue, error) {
+	values, origins, _, err := resolveProjectValues(filePath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = interpolateProjectValues(values, filepath.Dir(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	var result []ProjectValue
+	flattenProjectValues(values, "", origins, &result)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+
+	return result, nil
+}
+
+func flattenProjectValues(values map[string]any, prefix string, origins map[string][]string, result *[]ProjectValue) {
+	for key, val := range values {
+		if m, ok := val.(map[string]any); ok {
+			flattenProjectValues(m, prefix+key+".", origins, result)
+			continue
+		}
+
+		*result = append(*result, ProjectValue{
+			Key:     prefix + key,
+			Value:   val,
+			Origins: origins[prefix+key],
+		})
+	}
+}
+
+// resolveProjectValues merges project file with base files it extends. It
+// also returns project file followed by all base files.
+func resolveProjectValues(filePath string, visited []string) (map[string]any, map[string][]string, []string, error) {
+	file, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if slices.Contains(visited, file) {
+		return nil, nil, nil, fmt.Errorf("extends cycle: %s", strings.Join(append(visited, file), " -> "))
+	}
+	visited = append(visited, file)
+
+	values, err := ReadProjectValues(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	_, err = MigrateProjectValues(values)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	if len(visited) > 1 {
+		rebaseProjectPaths(values, filepath.Dir(file))
+	}
+
+	base := map[string]any{}
+	origins := map[string][]string{}
+
+	if extends, ok := values["extends"]; ok {
+		name, ok := extends.(string)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("%s: extends must be a string", file)
+		}
+
+		baseFile, err := findBaseProject(filepath.Dir(file), name)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		base, origins, visited, err = resolveProjectValues(baseFile, visited)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		delete(base, "extends")
+	}
+
+	mergeProjectValues(base, values, file, "", origins)
+
+	return base, origins, visited, nil
+}
+
+// projectPathKeys are project values holding paths relative to the file
+// which sets them.
+var projectPathKeys = [][]string{
+	{"outputFolder"},
+	{"bibliography"},
+	{"sources"},
+	{"exclude"},
+	{"assets"},
+	{"html", "template"},
+	{"html", "images", "cacheFolder"},
+	{"pdf", "template"},
+	{"pdf", "merge", "before"},
+	{"pdf", "merge", "after"},
+	{"cover", "background"},
+}
+
+var languagePathKeys = [][]string{
+	{"sources"},
+	{"exclude"},
+	{"pdf", "merge", "before"},
+	{"pdf", "merge", "after"},
+	{"cover", "background"},
+}
+
+// rebaseProjectPaths makes relative paths in base file absolute against its
+// folder, because project which extends it can be anywhere. Values starting
+// with variable are left as they are.
+func rebaseProjectPaths(values map[string]any, dir string) {
+	for _, key := range projectPathKeys {
+		rebaseProjectPath(values, key, dir)
+	}
+
+	languages, _ := values["languages"].(map[string]any)
+	for _, val := range languages {
+		if l, ok := val.(map[string]any); ok {
+			for _, key := range languagePathKeys {
+				rebaseProjectPath(l, key, dir)
+			}
+		}
+	}
+}
+
+func rebaseProjectPath(values map[string]any, key []string, dir string) {
+	for _, name := range key[:len(key)-1] {
+		m, ok := lookupProjectKey(values, name).(map[string]any)
+		if !ok {
+			return
+		}
+		values = m
+	}
+
+	name := key[len(key)-1]
+	for _, k := range []string{name, name + appendSuffix} {
+		switch val := values[k].(type) {
+		case string:
+			values[k] = rebasePath(val, dir)
+		case []any:
+			for i, item := range val {
+				if s, ok := item.(string); ok {
+					val[i] = rebasePath(s, dir)
+				}
+			}
+		}
+	}
+}
+
+func lookupProjectKey(values map[string]any, name string) any {
+	if val, ok := values[name]; ok {
+		return val
+	}
+
+	return values[name+appendSuffix]
+}
+
+func rebasePath(pth, dir string) string {
+	if pth == "" || path.IsAbs(pth) || strings.HasPrefix(pth, "$") {
+		return pth
+	}
+
+	return path.Join(filepath.ToSlash(dir), pth)
+}
+
+func ReadProjectValues(filePath string) (map[string]any, error) {
+	format, err := ProjectFileFormat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+	switch format {
+	case "json":
+		err = json.Unmarshal(content, &values)
+	case "yaml":
+		err = yaml.Unmarshal(content, &values)
+	case "toml":
+		err = toml.Unmarshal(content, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	return values, nil
+}
+
+func SaveProjectValues(filePath string, values map[string]any) error {
+	if HasAppendValues(values) {
+		return writeProjectFile(filePath, values)
+	}
+
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	var project Project
+	err = json.Unmarshal(content, &project)
+	if err != nil {
+		return err
+	}
+
+	return SaveProject(filePath, &project)
+}
+
+func HasAppendValues(values map[string]any) bool {
+	for key, val := range values {
+		if strings.HasSuffix(key, appendSuffix) {
+			return true
+		}
+
+		if m, ok := val.(map[string]any); ok && HasAppendValues(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func findBaseProject(dir, name string) (string, error) {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = []string{path.Join(dir, name)}
+
+		configDir, err := UserConfigDir()
+		if err == nil && !strings.Contains(name, "/") {
+			candidates = append(candidates, path.Join(configDir, name))
+			if path.Ext(name) == "" {
+				for _, fileName := range ProjectFileNames {
+					candidates = append(candidates, path.Join(configDir, name+path.Ext(fileName)))
+				}
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("base project '%s' not found", name)
+}
+
+func mergeProjectValues(dst, src map[string]any, origin, prefix string, origins map[string][]string) {
+	for key, val := range src {
+		appendValue := strings.HasSuffix(key, appendSuffix)
+		key = strings.TrimSuffix(key, appendSuffix)
+		fullKey := prefix + key
+
+		srcMap, srcIsMap := val.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+
+		switch {
+		case srcIsMap && dstIsMap:
+			mergeProjectValues(dstMap, srcMap, origin, fullKey+".", origins)
+		case appendValue:
+			base, _ := dst[key].([]any)
+			items, ok := val.([]any)
+			if !ok {
+				items = []any{val}
+			}
+
+			dst[key] = append(slices.Clone(base), items...)
+			origins[fullKey] = append(origins[fullKey], origin)
+		case srcIsMap:
+			clearOrigins(origins, fullKey)
+			m := map[string]any{}
+			dst[key] = m
+			mergeProjectValues(m, srcMap, origin, fullKey+".", origins)
+		default:
+			clearOrigins(origins, fullKey)
+			dst[key] = val
+			origins[fullKey] = []string{origin}
+		}
+	}
+}
+
+func clearOrigins(origins map[string][]string, key string) {
+	for k := range origins {
+		if k == key || strings.HasPrefix(k, key+".") {
+			delete(origins, k)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var reVariable = regexp.MustCompile(`\$(\$?)\{([^{}]*)\}`)
+
+type interpolator struct {
+	values    map[string]any
+	dir       string
+	cache     map[string]string
+	resolving map[string]bool
+}
+
+func interpolateProjectValues(values map[string]any, dir string) error {
+	ip := &interpolator{
+		values:    values,
+		dir:       dir,
+		cache:     map[string]string{},
+		resolving: map[string]bool{},
+	}
+
+	return ip.walkMap(values, "")
+}
+
+func (ip *interpolator) walkMap(values map[string]any, prefix string) error {
+	for key, val := range values {
+		res, err := ip.walk(val, prefix+key)
+		if err != nil {
+			return err
+		}
+		values[key] = res
+	}
+
+	return nil
+}
+
+func (ip *interpolator) walk(val any, key string) (any, error) {
+	switch v := val.(type) {
+	case string:
+		return ip.resolveKey(key, v)
+	case map[string]any:
+		return v, ip.walkMap(v, key+".")
+	case []any:
+		for i, item := range v {
+			res, err := ip.walk(item, fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = res
+		}
+		return v, nil
+	}
+
+	return val, nil
+}
+
+func (ip *interpolator) resolveKey(key, val string) (string, error) {
+	if res, ok := ip.cache[key]; ok {
+		return res, nil
+	}
+
+	if ip.resolving[key] {
+		return "", fmt.Errorf("%s: variable references itself", key)
+	}
+	ip.resolving[key] = true
+	defer delete(ip.resolving, key)
+
+	res, err := ip.interpolate(val)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", key, err)
+	}
+	ip.cache[key] = res
+
+	return res, nil
+}
+
+func (ip *interpolator) interpolate(val string) (string, error) {
+	var err error
+
+	res := reVariable.ReplaceAllStringFunc(val, func(match string) string {
+		m := reVariable.FindStringSubmatch(match)
+		if m[1] != "" {
+			return match[1:]
+		}
+
+		name, def, hasDefault := strings.Cut(m[2], ":-")
+		name = strings.TrimSpace(name)
+
+		res, ok, lerr := ip.lookup(name)
+		if lerr != nil && err == nil {
+			err = lerr
+		}
+
+		if !ok || res == "" {
+			if hasDefault {
+				return def
+			}
+			if !ok && err == nil {
+				err = fmt.Errorf("undefined variable '%s'", name)
+			}
+		}
+
+		return res
+	})
+
+	return res, err
+}
+
+func (ip *interpolator) lookup(name string) (string, bool, error) {
+	if val, ok := lookupProjectValue(ip.values, name); ok {
+		switch v := val.(type) {
+		case string:
+			res, err := ip.resolveKey(name, v)
+			return res, true, err
+		case map[string]any, []any:
+			return "", false, fmt.Errorf("variable '%s' is not a string", name)
+		default:
+			return fmt.Sprint(v), true, nil
+		}
+	}
+
+	switch name {
+	case "date":
+		return buildTime().Format("2006-01-02"), true, nil
+	case "year":
+		return buildTime().Format("2006"), true, nil
+	case "git.describe":
+		return ip.git("describe", "--tags", "--always", "--dirty")
+	case "git.commit":
+		return ip.git("rev-parse", "--short", "HEAD")
+	}
+
+	val, ok := os.LookupEnv(name)
+	return val, ok, nil
+}
+
+func (ip *interpolator) git(args ...string) (string, bool, error) {
+	key := "$git " + args[0]
+	if res, ok := ip.cache[key]; ok {
+		return res, true, nil
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = ip.dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false, nil
+	}
+
+	res := strings.TrimSpace(string(out))
+	ip.cache[key] = res
+
+	return res, true, nil
+}
+
+func lookupProjectValue(values map[string]any, name string) (any, bool) {
+	var val any = values
+	for _, key := range strings.Split(name, ".") {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		val, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return val, true
+}
+
+func buildTime() time.Time {
+	epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+
+	return time.Unix(epoch, 0).UTC()
+}
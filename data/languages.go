@@ -0,0 +1,90 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+func (p *Project) LanguageKeys() []string {
+	return slices.Sorted(maps.Keys(p.Languages))
+}
+
+func (p *Project) LanguageProject(key string) *Project {
+	l := p.Languages[key]
+
+	project := *p
+	project.Languages = nil
+	project.OutputFolder = path.Join(p.OutputFolder, key)
+	project.SitePath = path.Join(p.SitePath, key)
+
+	project.Lang = l.Lang
+	if project.Lang == "" {
+		project.Lang = key
+	}
+
+	if l.Name != "" {
+		project.Name = l.Name
+	}
+
+	if len(l.Sources) > 0 {
+		project.Sources = l.Sources
+	}
+	project.Exclude = append(slices.Clone(p.Exclude), l.Exclude...)
+
+	project.Metadata = maps.Clone(p.Metadata)
+	if project.Metadata == nil {
+		project.Metadata = map[string]string{}
+	}
+	maps.Copy(project.Metadata, l.Metadata)
+
+	if len(l.Pdf.Merge.Before) > 0 {
+		project.Pdf.Merge.Before = l.Pdf.Merge.Before
+	}
+	if len(l.Pdf.Merge.After) > 0 {
+		project.Pdf.Merge.After = l.Pdf.Merge.After
+	}
+	overrideFields(reflect.ValueOf(&project.Cover).Elem(), reflect.ValueOf(l.Cover))
+
+	return &project
+}
+
+// overrideFields copies fields of src which are set over dst.
+func overrideFields(dst, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		if !src.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func (l ProjectLanguage) DisplayLabel(key string) string {
+	if l.Label != "" {
+		return l.Label
+	}
+
+	return strings.ToUpper(key)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+const CurrentSchemaVersion = 2
+
+type migration struct {
+	version     int
+	description string
+	migrate     func(values map[string]any) error
+}
+
+var migrations = []migration{
+	{
+		version:     2,
+		description: "move --top-level-division from pdf.args to pdf.topLevelDivision",
+		migrate:     migrateTopLevelDivision,
+	},
+}
+
+func ProjectSchemaVersion(values map[string]any) int {
+	switch v := values["schemaVersion"].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+
+	return 1
+}
+
+func MigrateProjectValues(values map[string]any) ([]string, error) {
+	version := ProjectSchemaVersion(values)
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("schema version %d is newer than supported version %d, update author", version, CurrentSchemaVersion)
+	}
+
+	var applied []string
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		err := m.migrate(values)
+		if err != nil {
+			return nil, fmt.Errorf("migration to schema version %d: %v", m.version, err)
+		}
+
+		applied = append(applied, fmt.Sprintf("%d: %s", m.version, m.description))
+	}
+
+	if version != CurrentSchemaVersion {
+		values["schemaVersion"] = CurrentSchemaVersion
+	}
+
+	return applied, nil
+}
+
+func migrateTopLevelDivision(values map[string]any) error {
+	pdf, ok := values["pdf"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range []string{"args", "args" + appendSuffix} {
+		args, ok := pdf[key].([]any)
+		if !ok {
+			continue
+		}
+
+		var rest []any
+		for i := 0; i < len(args); i++ {
+			arg, _ := args[i].(string)
+
+			division, found := strings.CutPrefix(arg, "--top-level-division=")
+			if !found && arg == "--top-level-division" && i+1 < len(args) {
+				division, _ = args[i+1].(string)
+				found = true
+				i++
+			}
+
+			if !found {
+				rest = append(rest, args[i])
+				continue
+			}
+
+			if _, ok := pdf["topLevelDivision"]; !ok {
+				pdf["topLevelDivision"] = division
+			}
+		}
+
+		if len(rest) == 0 {
+			delete(pdf, key)
+		} else {
+			pdf[key] = rest
+		}
+	}
+
+	return nil
+}
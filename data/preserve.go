@@ -0,0 +1,198 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileLosses lists what would be lost when values read from filePath
+// are saved with SaveProjectValues: comments and keys project does not know.
+func ProjectFileLosses(filePath string, values map[string]any) ([]string, error) {
+	var losses []string
+
+	hasComments, err := projectFileHasComments(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if hasComments {
+		losses = append(losses, "comments")
+	}
+
+	unknown, err := unknownProjectKeys(values)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range unknown {
+		losses = append(losses, fmt.Sprintf("unknown key '%s'", key))
+	}
+
+	return losses, nil
+}
+
+func projectFileHasComments(filePath string) (bool, error) {
+	format, err := ProjectFileFormat(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	switch format {
+	case "yaml":
+		var node yaml.Node
+		err = yaml.Unmarshal(content, &node)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", filePath, err)
+		}
+		return yamlHasComments(&node), nil
+	case "toml":
+		return tomlHasComments(content), nil
+	}
+
+	return false, nil
+}
+
+func yamlHasComments(node *yaml.Node) bool {
+	if node.HeadComment != "" || node.LineComment != "" || node.FootComment != "" {
+		return true
+	}
+
+	return slices.ContainsFunc(node.Content, yamlHasComments)
+}
+
+// tomlHasComments looks for # outside of quoted strings. Multiline strings
+// are rare in project files and are not tracked.
+func tomlHasComments(content []byte) bool {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		var quote byte
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote == '"' {
+					i++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '#':
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// unknownProjectKeys returns keys which do not survive round trip through
+// Project. Keys holding zero values are dropped on save anyway and are not
+// reported.
+func unknownProjectKeys(values map[string]any) ([]string, error) {
+	if HasAppendValues(values) {
+		return nil, nil
+	}
+
+	content, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var project Project
+	err = json.Unmarshal(content, &project)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = json.Marshal(&project)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]any{}
+	err = json.Unmarshal(content, &known)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	collectUnknownKeys(values, known, "", &unknown)
+	slices.Sort(unknown)
+
+	return unknown, nil
+}
+
+func collectUnknownKeys(values, known map[string]any, prefix string, unknown *[]string) {
+	for key, val := range values {
+		knownVal, ok := known[key]
+		if !ok {
+			if !isZeroProjectValue(val) {
+				*unknown = append(*unknown, prefix+key)
+			}
+			continue
+		}
+
+		m, isMap := val.(map[string]any)
+		knownMap, knownIsMap := knownVal.(map[string]any)
+		if isMap && knownIsMap {
+			collectUnknownKeys(m, knownMap, prefix+key+".", unknown)
+		}
+	}
+}
+
+func isZeroProjectValue(val any) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case int:
+		return v == 0
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		for _, item := range v {
+			if !isZeroProjectValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
@@ -1,51 +1,378 @@
 package data
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
+type ProjectLinkCheck struct {
+	Enabled bool     `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty" desc:"Run link check after every html build"`
+	Allow   []string `json:"allow,omitempty" yaml:"allow,omitempty" toml:"allow,omitempty" desc:"External url patterns which are not checked"`
+	Deny    []string `json:"deny,omitempty" yaml:"deny,omitempty" toml:"deny,omitempty" desc:"External url patterns which are always reported"`
+}
+
+type ProjectHtmlImages struct {
+	Optimize    bool   `json:"optimize,omitempty" yaml:"optimize,omitempty" toml:"optimize,omitempty" desc:"Resize and compress images"`
+	Quality     int    `json:"quality,omitempty" yaml:"quality,omitempty" toml:"quality,omitempty,omitzero" desc:"Jpeg quality from 1 to 100"`
+	Widths      []int  `json:"widths,omitempty" yaml:"widths,omitempty" toml:"widths,omitempty" desc:"Image widths generated for srcset"`
+	Sizes       string `json:"sizes,omitempty" yaml:"sizes,omitempty" toml:"sizes,omitempty" desc:"Value of sizes attribute"`
+	CacheFolder string `json:"cacheFolder,omitempty" yaml:"cacheFolder,omitempty" toml:"cacheFolder,omitempty" desc:"Folder for cached optimized images"`
+}
+
+type ProjectHtmlReader struct {
+	Theme     string `json:"theme,omitempty" yaml:"theme,omitempty" toml:"theme,omitempty" desc:"Color theme" enum:"light,dark,sepia"`
+	FontSize  int    `json:"fontSize,omitempty" yaml:"fontSize,omitempty" toml:"fontSize,omitempty,omitzero" desc:"Font size in pixels"`
+	TextAlign string `json:"textAlign,omitempty" yaml:"textAlign,omitempty" toml:"textAlign,omitempty" desc:"Paragraph alignment" enum:"justify,left"`
+}
+
+type ProjectHtmlAccessibility struct {
+	Check bool `json:"check,omitempty" yaml:"check,omitempty" toml:"check,omitempty" desc:"Report accessibility issues after html build"`
+	Fail  bool `json:"fail,omitempty" yaml:"fail,omitempty" toml:"fail,omitempty" desc:"Fail build when accessibility issues are found"`
+}
+
 type ProjectHtml struct {
-	OutputFolder string   `json:"outputFolder,omitempty"`
-	Template     string   `json:"template,omitempty"`
-	Args         []string `json:"args,omitempty"`
+	OutputFolder  string                   `json:"outputFolder,omitempty" yaml:"outputFolder,omitempty" toml:"outputFolder,omitempty" desc:"Html output folder inside outputFolder"`
+	Template      string                   `json:"template,omitempty" yaml:"template,omitempty" toml:"template,omitempty" desc:"Html template folder"`
+	Args          []string                 `json:"args,omitempty" yaml:"args,omitempty" toml:"args,omitempty" desc:"Extra pandoc arguments for html"`
+	LinkCheck     ProjectLinkCheck         `json:"linkCheck,omitempty,omitzero" yaml:"linkCheck,omitempty" toml:"linkCheck,omitempty" desc:"Check links after html build"`
+	Images        ProjectHtmlImages        `json:"images,omitempty,omitzero" yaml:"images,omitempty" toml:"images,omitempty" desc:"Responsive image options"`
+	Production    bool                     `json:"production,omitempty" yaml:"production,omitempty" toml:"production,omitempty" desc:"Minify and fingerprint html assets"`
+	Reader        ProjectHtmlReader        `json:"reader,omitempty,omitzero" yaml:"reader,omitempty" toml:"reader,omitempty" desc:"Default reader preferences"`
+	Accessibility ProjectHtmlAccessibility `json:"accessibility,omitempty,omitzero" yaml:"accessibility,omitempty" toml:"accessibility,omitempty" desc:"Accessibility audit options"`
+}
+
+type ProjectPdfPrint struct {
+	Enabled        bool   `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty" desc:"Build print-ready pdf"`
+	OutputFileName string `json:"outputFileName,omitempty" yaml:"outputFileName,omitempty" toml:"outputFileName,omitempty" desc:"Print pdf file name"`
+	TrimSize       string `json:"trimSize,omitempty" yaml:"trimSize,omitempty" toml:"trimSize,omitempty" desc:"Trim size, paper name like a5 or 148mm x 210mm"`
+	Bleed          string `json:"bleed,omitempty" yaml:"bleed,omitempty" toml:"bleed,omitempty" desc:"Bleed around trim size, like 3mm"`
+	CropMarks      bool   `json:"cropMarks,omitempty" yaml:"cropMarks,omitempty" toml:"cropMarks,omitempty" desc:"Add crop marks"`
+	Imposition     string `json:"imposition,omitempty" yaml:"imposition,omitempty" toml:"imposition,omitempty" desc:"Page imposition, booklet or n-up like 2x1"`
+}
+
+type ProjectPdfMerge struct {
+	Before []string `json:"before,omitempty" yaml:"before,omitempty" toml:"before,omitempty" desc:"Pdf files added before document"`
+	After  []string `json:"after,omitempty" yaml:"after,omitempty" toml:"after,omitempty" desc:"Pdf files added after document"`
 }
 
 type ProjectPdf struct {
-	OutputFolder   string   `json:"outputFolder,omitempty"`
-	Template       string   `json:"template,omitempty"`
-	OutputFileName string   `json:"outputFileName,omitempty"`
-	Args           []string `json:"args,omitempty"`
+	OutputFolder     string          `json:"outputFolder,omitempty" yaml:"outputFolder,omitempty" toml:"outputFolder,omitempty" desc:"Pdf output folder inside outputFolder"`
+	Template         string          `json:"template,omitempty" yaml:"template,omitempty" toml:"template,omitempty" desc:"Pdf template folder"`
+	OutputFileName   string          `json:"outputFileName,omitempty" yaml:"outputFileName,omitempty" toml:"outputFileName,omitempty" desc:"Pdf file name"`
+	Args             []string        `json:"args,omitempty" yaml:"args,omitempty" toml:"args,omitempty" desc:"Extra pandoc arguments for pdf"`
+	Subject          string          `json:"subject,omitempty" yaml:"subject,omitempty" toml:"subject,omitempty" desc:"Pdf subject metadata"`
+	Keywords         []string        `json:"keywords,omitempty" yaml:"keywords,omitempty" toml:"keywords,omitempty" desc:"Pdf keywords metadata"`
+	BookmarkDepth    int             `json:"bookmarkDepth,omitempty" yaml:"bookmarkDepth,omitempty" toml:"bookmarkDepth,omitempty,omitzero" desc:"Depth of pdf bookmarks"`
+	PdfA             string          `json:"pdfa,omitempty" yaml:"pdfa,omitempty" toml:"pdfa,omitempty" desc:"PDF/A conformance level" enum:"1b,2b"`
+	KeepIntermediate bool            `json:"keepIntermediate,omitempty" yaml:"keepIntermediate,omitempty" toml:"keepIntermediate,omitempty" desc:"Keep intermediate tex and log files"`
+	Draft            bool            `json:"draft,omitempty" yaml:"draft,omitempty" toml:"draft,omitempty" desc:"Build draft with watermark, line numbers and review notes"`
+	Print            ProjectPdfPrint `json:"print,omitempty,omitzero" yaml:"print,omitempty" toml:"print,omitempty" desc:"Print-ready pdf options"`
+	TopLevelDivision string          `json:"topLevelDivision,omitempty" yaml:"topLevelDivision,omitempty" toml:"topLevelDivision,omitempty" desc:"Treat top-level headings as given division" enum:"default,section,chapter,part"`
+	Split            string          `json:"split,omitempty" yaml:"split,omitempty" toml:"split,omitempty" desc:"Also write one pdf per chapter" enum:"file,heading"`
+	Merge            ProjectPdfMerge `json:"merge,omitempty,omitzero" yaml:"merge,omitempty" toml:"merge,omitempty" desc:"Pdf files merged with generated document"`
+}
+
+type ProjectCover struct {
+	Enabled        bool   `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty" desc:"Generate cover on every pdf build"`
+	Background     string `json:"background,omitempty" yaml:"background,omitempty" toml:"background,omitempty" desc:"Cover background image or pdf"`
+	Subtitle       string `json:"subtitle,omitempty" yaml:"subtitle,omitempty" toml:"subtitle,omitempty" desc:"Cover subtitle"`
+	TextColor      string `json:"textColor,omitempty" yaml:"textColor,omitempty" toml:"textColor,omitempty" desc:"Cover text color as hex, like FFFFFF"`
+	TrimSize       string `json:"trimSize,omitempty" yaml:"trimSize,omitempty" toml:"trimSize,omitempty" desc:"Cover trim size, paper name like a5 or 148mm x 210mm"`
+	Spine          bool   `json:"spine,omitempty" yaml:"spine,omitempty" toml:"spine,omitempty" desc:"Generate spine sized from page count"`
+	Back           bool   `json:"back,omitempty" yaml:"back,omitempty" toml:"back,omitempty" desc:"Generate back cover"`
+	BackText       string `json:"backText,omitempty" yaml:"backText,omitempty" toml:"backText,omitempty" desc:"Back cover text"`
+	PaperThickness string `json:"paperThickness,omitempty" yaml:"paperThickness,omitempty" toml:"paperThickness,omitempty" desc:"Thickness of one sheet, like 0.1mm"`
+	Dpi            int    `json:"dpi,omitempty" yaml:"dpi,omitempty" toml:"dpi,omitempty,omitzero" desc:"Resolution of png cover"`
+	Prepend        bool   `json:"prepend,omitempty" yaml:"prepend,omitempty" toml:"prepend,omitempty" desc:"Add front cover to pdf document"`
+}
+
+type ProjectLanguage struct {
+	Lang     string             `json:"lang,omitempty" yaml:"lang,omitempty" toml:"lang,omitempty" desc:"Language as BCP 47 tag, defaults to the key"`
+	Label    string             `json:"label,omitempty" yaml:"label,omitempty" toml:"label,omitempty" desc:"Label in language switcher"`
+	Name     string             `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty" desc:"Translated document title"`
+	Sources  []string           `json:"sources,omitempty" yaml:"sources,omitempty" toml:"sources,omitempty" desc:"Sources of this language"`
+	Exclude  []string           `json:"exclude,omitempty" yaml:"exclude,omitempty" toml:"exclude,omitempty" desc:"Files removed from sources of this language"`
+	Metadata map[string]string  `json:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty" desc:"Pandoc metadata overrides for this language"`
+	Pdf      ProjectLanguagePdf `json:"pdf,omitempty,omitzero" yaml:"pdf,omitempty" toml:"pdf,omitempty" desc:"Pdf options of this language"`
+	Cover    ProjectCover       `json:"cover,omitempty,omitzero" yaml:"cover,omitempty" toml:"cover,omitempty" desc:"Cover options of this language, set values override project cover"`
+}
+
+type ProjectLanguagePdf struct {
+	Merge ProjectPdfMerge `json:"merge,omitempty,omitzero" yaml:"merge,omitempty" toml:"merge,omitempty" desc:"Pdf files merged with document of this language, replace project pdf.merge lists"`
 }
 
 type Project struct {
-	Name           string      `json:"name,omitempty"`
-	Author         string      `json:"author,omitempty"`
-	Version        string      `json:"version,omitempty"`
-	Format         string      `json:"format,omitempty"`
-	TableOfContent bool        `json:"toc,omitempty"`
-	Bibliography   string      `json:"bibliography,omitempty"`
-	Biblatex       bool        `json:"biblatex,omitempty"`
-	Sources        []string    `json:"sources,omitempty"`
-	Assets         []string    `json:"assets,omitempty"`
-	OutputFolder   string      `json:"outputFolder,omitempty"`
-	Targets        []string    `json:"targets,omitempty"`
-	Html           ProjectHtml `json:"html,omitempty"`
-	Pdf            ProjectPdf  `json:"pdf,omitempty"`
-}
-
-func LoadProject(filepath string) (*Project, error) {
+	Schema         string                     `json:"$schema,omitempty" yaml:"$schema,omitempty" toml:"$schema,omitempty" desc:"JSON Schema used by editors to validate this file"`
+	SchemaVersion  int                        `json:"schemaVersion,omitempty" yaml:"schemaVersion,omitempty" toml:"schemaVersion,omitempty,omitzero" desc:"Version of project file schema, upgrade with author migrate"`
+	Extends        string                     `json:"extends,omitempty" yaml:"extends,omitempty" toml:"extends,omitempty" desc:"Base project file (relative path or name in user config directory) merged into this one"`
+	Name           string                     `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty" desc:"Document title"`
+	Author         string                     `json:"author,omitempty" yaml:"author,omitempty" toml:"author,omitempty" desc:"Document author"`
+	Version        string                     `json:"version,omitempty" yaml:"version,omitempty" toml:"version,omitempty" desc:"Document version"`
+	SiteUrl        string                     `json:"siteUrl,omitempty" yaml:"siteUrl,omitempty" toml:"siteUrl,omitempty" desc:"Public url of html output, required for canonical links and sitemap"`
+	Lang           string                     `json:"lang,omitempty" yaml:"lang,omitempty" toml:"lang,omitempty" desc:"Document language as BCP 47 tag, like en or sr-Latn"`
+	Metadata       map[string]string          `json:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty" desc:"Pandoc metadata overrides"`
+	Format         string                     `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty" desc:"Pandoc input format of sources" enum:"markdown,commonmark,commonmark_x,gfm,markdown_strict,markdown_phpextra,markdown_mmd"`
+	TableOfContent bool                       `json:"toc,omitempty" yaml:"toc,omitempty" toml:"toc,omitempty" desc:"Generate table of contents"`
+	Bibliography   string                     `json:"bibliography,omitempty" yaml:"bibliography,omitempty" toml:"bibliography,omitempty" desc:"Bibliography file"`
+	Biblatex       bool                       `json:"biblatex,omitempty" yaml:"biblatex,omitempty" toml:"biblatex,omitempty" desc:"Use biblatex for citations in pdf"`
+	Sources        []string                   `json:"sources,omitempty" yaml:"sources,omitempty" toml:"sources,omitempty" desc:"Source files, directories or glob patterns in build order"`
+	Exclude        []string                   `json:"exclude,omitempty" yaml:"exclude,omitempty" toml:"exclude,omitempty" desc:"Files, directories or glob patterns removed from sources"`
+	Assets         []string                   `json:"assets,omitempty" yaml:"assets,omitempty" toml:"assets,omitempty" desc:"Asset folders copied to html output"`
+	OutputFolder   string                     `json:"outputFolder,omitempty" yaml:"outputFolder,omitempty" toml:"outputFolder,omitempty" desc:"Folder for all build outputs"`
+	Targets        []string                   `json:"targets,omitempty" yaml:"targets,omitempty" toml:"targets,omitempty" desc:"Output formats to build" enum:"html,pdf"`
+	Html           ProjectHtml                `json:"html,omitempty,omitzero" yaml:"html,omitempty" toml:"html,omitempty" desc:"Html output options"`
+	Pdf            ProjectPdf                 `json:"pdf,omitempty,omitzero" yaml:"pdf,omitempty" toml:"pdf,omitempty" desc:"Pdf output options"`
+	Cover          ProjectCover               `json:"cover,omitempty,omitzero" yaml:"cover,omitempty" toml:"cover,omitempty" desc:"Cover generator options"`
+	Languages      map[string]ProjectLanguage `json:"languages,omitempty" yaml:"languages,omitempty" toml:"languages,omitempty" desc:"Translations built into outputFolder/<key>"`
+
+	File     string   `json:"-" yaml:"-" toml:"-"`
+	Dir      string   `json:"-" yaml:"-" toml:"-"`
+	Files    []string `json:"-" yaml:"-" toml:"-"`
+	SitePath string   `json:"-" yaml:"-" toml:"-"`
+}
+
+var projectPath string
+
+var ProjectFileNames = []string{
+	"project.json",
+	"project.yaml",
+	"project.yml",
+	"project.toml",
+}
+
+func FindProjectFile(dir string) (string, error) {
+	for _, name := range ProjectFileNames {
+		filePath := path.Join(dir, name)
+		_, err := os.Stat(filePath)
+		if err == nil {
+			return filePath, nil
+		}
+	}
+
+	return "", fmt.Errorf("project file not found, expected one of %s", strings.Join(ProjectFileNames, ", "))
+}
+
+func ProjectFileFormat(filePath string) (string, error) {
+	switch path.Ext(filePath) {
+	case ".json":
+		return "json", nil
+	case ".yaml", ".yml":
+		return "yaml", nil
+	case ".toml":
+		return "toml", nil
+	}
+
+	return "", fmt.Errorf("unsupported project file format '%s'", path.Ext(filePath))
+}
+
+func LoadProject(filePath string) (*Project, error) {
 	var project Project
 
-	data, err := os.ReadFile(filepath)
+	values, _, files, err := resolveProjectValues(filePath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = interpolateProjectValues(values, filepath.Dir(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	content, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &project)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	err = project.setFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	project.Files = files
+	project.resolvePaths()
+
+	return &project, nil
+}
+
+func LoadProjectRaw(filePath string) (*Project, error) {
+	var project Project
+
+	format, err := ProjectFileFormat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(data, &project)
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, &project)
+	case "yaml":
+		err = yaml.Unmarshal(data, &project)
+	case "toml":
+		err = toml.Unmarshal(data, &project)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	err = project.setFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	return &project, nil
 }
+
+func (p *Project) setFile(filePath string) error {
+	file, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	p.File = file
+	p.Dir = filepath.Dir(file)
+
+	return nil
+}
+
+// resolvePaths makes relative project paths absolute against project folder,
+// so project can be built without changing working directory.
+func (p *Project) resolvePaths() {
+	p.OutputFolder = p.ResolvePath(p.OutputFolder)
+	p.Bibliography = p.ResolvePath(p.Bibliography)
+	p.Sources = p.resolvePathList(p.Sources)
+	p.Exclude = p.resolvePathList(p.Exclude)
+	p.Assets = p.resolvePathList(p.Assets)
+	p.Html.Template = p.ResolvePath(p.Html.Template)
+	p.Html.Images.CacheFolder = p.ResolvePath(p.Html.Images.CacheFolder)
+	p.Pdf.Template = p.ResolvePath(p.Pdf.Template)
+	p.Pdf.Merge.Before = p.resolvePathList(p.Pdf.Merge.Before)
+	p.Pdf.Merge.After = p.resolvePathList(p.Pdf.Merge.After)
+	p.Cover.Background = p.ResolvePath(p.Cover.Background)
+
+	for key, l := range p.Languages {
+		l.Sources = p.resolvePathList(l.Sources)
+		l.Exclude = p.resolvePathList(l.Exclude)
+		l.Pdf.Merge.Before = p.resolvePathList(l.Pdf.Merge.Before)
+		l.Pdf.Merge.After = p.resolvePathList(l.Pdf.Merge.After)
+		l.Cover.Background = p.ResolvePath(l.Cover.Background)
+		p.Languages[key] = l
+	}
+}
+
+// ResolvePath returns pth relative to project folder, unless it is absolute.
+func (p *Project) ResolvePath(pth string) string {
+	if pth == "" || path.IsAbs(pth) {
+		return pth
+	}
+
+	return path.Join(filepath.ToSlash(p.Dir), pth)
+}
+
+func (p *Project) resolvePathList(pths []string) []string {
+	if len(pths) == 0 {
+		return pths
+	}
+
+	res := make([]string, len(pths))
+	for i, pth := range pths {
+		res[i] = p.ResolvePath(pth)
+	}
+
+	return res
+}
+
+func SetProjectPath(filePath string) {
+	projectPath = filePath
+}
+
+func LocateProject() (string, error) {
+	if projectPath != "" {
+		info, err := os.Stat(projectPath)
+		if err != nil {
+			return "", err
+		}
+
+		if info.IsDir() {
+			return FindProjectFile(projectPath)
+		}
+
+		return projectPath, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		filePath, err := FindProjectFile(dir)
+		if err == nil {
+			return filePath, nil
+		}
+
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	return "", fmt.Errorf("project file not found in %s or any parent directory, expected one of %s",
+		cwd, strings.Join(ProjectFileNames, ", "))
+}
+
+func OpenProject() (*Project, error) {
+	filePath, err := LocateProject()
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadProject(filePath)
+}
+
+func SaveProject(filePath string, project *Project) error {
+	return writeProjectFile(filePath, project)
+}
+
+func writeProjectFile(filePath string, project any) error {
+	format, err := ProjectFileFormat(filePath)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	switch format {
+	case "json":
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(project)
+	case "yaml":
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		err = enc.Encode(project)
+	case "toml":
+		err = toml.NewEncoder(&b).Encode(project)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, b.Bytes(), 0644)
+}
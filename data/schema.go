@@ -0,0 +1,108 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const (
+	SchemaFileName = "project.schema.json"
+	jsonSchemaUrl  = "http://json-schema.org/draft-07/schema#"
+)
+
+type JsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *JsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*JsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+}
+
+func ProjectSchema() ([]byte, error) {
+	schema := schemaForType(reflect.TypeFor[Project](), "", nil)
+	schema.Schema = jsonSchemaUrl
+	schema.Title = "author project"
+	schema.Description = "Project file of author command line tool"
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaForType builds schema from struct fields. Description and allowed
+// values come from desc and comma separated enum struct tags.
+func schemaForType(t reflect.Type, desc string, enum []string) *JsonSchema {
+	schema := &JsonSchema{
+		Description: desc,
+		Enum:        enum,
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema.Type = "string"
+	case reflect.Bool:
+		schema.Type = "boolean"
+	case reflect.Int, reflect.Int64:
+		schema.Type = "integer"
+	case reflect.Slice:
+		schema.Type = "array"
+		schema.Items = schemaForType(t.Elem(), "", enum)
+		schema.Enum = nil
+	case reflect.Map:
+		schema.Type = "object"
+		schema.AdditionalProperties = schemaForType(t.Elem(), "", nil)
+	case reflect.Struct:
+		schema.Type = "object"
+		schema.AdditionalProperties = false
+		schema.Properties = map[string]*JsonSchema{}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			var enum []string
+			if val := field.Tag.Get("enum"); val != "" {
+				enum = strings.Split(val, ",")
+			}
+
+			prop := schemaForType(field.Type, field.Tag.Get("desc"), enum)
+			schema.Properties[name] = prop
+
+			if field.Type.Kind() == reflect.Slice {
+				schema.Properties[name+appendSuffix] = &JsonSchema{
+					Description: "Appended to " + name + " from base project",
+					Type:        prop.Type,
+					Items:       prop.Items,
+				}
+			}
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package data
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/zivlakmilos/author/utils"
+)
+
+var sourceExtensions = []string{".md", ".markdown"}
+
+func (p *Project) ExpandSources() ([]string, error) {
+	var srcs []string
+	seen := map[string]bool{}
+
+	for _, src := range p.Sources {
+		files, err := expandSource(src)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if seen[file] || p.isExcluded(file) {
+				continue
+			}
+
+			seen[file] = true
+			srcs = append(srcs, file)
+		}
+	}
+
+	return srcs, nil
+}
+
+func (p *Project) isExcluded(file string) bool {
+	for _, pattern := range p.Exclude {
+		if utils.MatchGlob(pattern, file) {
+			return true
+		}
+
+		if !utils.HasGlobMeta(pattern) && strings.HasPrefix(file, path.Clean(pattern)+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func expandSource(src string) ([]string, error) {
+	src = path.Clean(src)
+
+	if utils.HasGlobMeta(src) {
+		files, err := walkSources(utils.GlobBase(src), func(file string) bool {
+			return utils.MatchGlob(src, file)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("source '%s' matched no files", src)
+		}
+
+		return files, nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil || !info.IsDir() {
+		return []string{src}, nil
+	}
+
+	return walkSources(src, func(file string) bool {
+		return slices.Contains(sourceExtensions, strings.ToLower(path.Ext(file)))
+	})
+}
+
+func walkSources(dir string, match func(file string) bool) ([]string, error) {
+	var files []string
+
+	err := fs.WalkDir(os.DirFS(dir), ".", func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && pth == "." {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		if pth != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		file := path.Join(dir, pth)
+		if !d.IsDir() && match(file) {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(files, func(a, b string) int {
+		if utils.NaturalLess(a, b) {
+			return -1
+		}
+		if utils.NaturalLess(b, a) {
+			return 1
+		}
+		return 0
+	})
+
+	return files, nil
+}
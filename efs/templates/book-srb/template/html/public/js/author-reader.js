@@ -0,0 +1,95 @@
+/*
+================================================================
+* author - Reader Preferences
+* Theme, font size and text alignment persisted in localStorage.
+* Defaults come from data attributes set on <html> during build.
+================================================================
+*/
+
+(function () {
+	"use strict";
+
+	var storageKey = "author-reader";
+	var root = document.documentElement;
+	var minFontSize = 12;
+	var maxFontSize = 24;
+
+	function load() {
+		try {
+			return JSON.parse(localStorage.getItem(storageKey)) || {};
+		} catch (e) {
+			return {};
+		}
+	}
+
+	function save(prefs) {
+		try {
+			localStorage.setItem(storageKey, JSON.stringify(prefs));
+		} catch (e) {
+		}
+	}
+
+	function apply(prefs) {
+		if (prefs.theme) {
+			root.setAttribute("data-theme", prefs.theme);
+		}
+		if (prefs.textAlign) {
+			root.setAttribute("data-text-align", prefs.textAlign);
+		}
+		if (prefs.fontSize) {
+			root.setAttribute("data-font-size", prefs.fontSize);
+		}
+
+		var fontSize = parseInt(root.getAttribute("data-font-size"), 10);
+		if (fontSize) {
+			root.style.setProperty("--author-font-size", fontSize + "px");
+		}
+
+		var buttons = document.querySelectorAll(".author-reader-panel [data-reader]");
+		for (var i = 0; i < buttons.length; i++) {
+			var key = buttons[i].getAttribute("data-reader");
+			var val = buttons[i].getAttribute("data-value");
+			var active = key === "theme" ? root.getAttribute("data-theme") === val
+				: key === "textAlign" ? root.getAttribute("data-text-align") === val
+				: false;
+			buttons[i].classList.toggle("active", active);
+		}
+	}
+
+	var prefs = load();
+	apply(prefs);
+
+	document.addEventListener("DOMContentLoaded", function () {
+		apply(prefs);
+
+		var reader = document.querySelector(".author-reader");
+		if (!reader) {
+			return;
+		}
+
+		reader.querySelector(".author-reader-toggle").addEventListener("click", function () {
+			reader.classList.toggle("open");
+		});
+
+		reader.addEventListener("click", function (e) {
+			var button = e.target.closest("[data-reader]");
+			if (!button) {
+				return;
+			}
+
+			var key = button.getAttribute("data-reader");
+			var val = button.getAttribute("data-value");
+
+			if (key === "fontSize") {
+				var fontSize = parseInt(root.getAttribute("data-font-size"), 10) || 16;
+				fontSize = Math.min(maxFontSize, Math.max(minFontSize, fontSize + parseInt(val, 10)));
+				prefs.fontSize = fontSize;
+			} else {
+				prefs[key] = val;
+			}
+
+			save(prefs);
+			apply(prefs);
+		});
+	});
+})();
@@ -1,12 +1,15 @@
 module github.com/zivlakmilos/author
 
-go 1.23.2
+go 1.24.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/charmbracelet/bubbles v0.20.0
 	github.com/charmbracelet/bubbletea v1.1.2
 	github.com/spf13/cobra v1.8.1
+	golang.org/x/image v0.18.0
 	golang.org/x/net v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -22,14 +22,15 @@ THE SOFTWARE.
 package utils
 
 import (
-	"embed"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 )
 
-func CopyDir(f embed.FS, origin, target string) error {
+func CopyDir(f fs.FS, origin, target string) error {
 	if _, err := os.Stat(target); os.IsNotExist(err) {
 		if err := os.MkdirAll(target, os.ModePerm); err != nil {
 			err = fmt.Errorf("error creating directory: %v", err)
@@ -37,14 +38,14 @@ func CopyDir(f embed.FS, origin, target string) error {
 		}
 	}
 
-	files, err := f.ReadDir(origin)
+	files, err := fs.ReadDir(f, origin)
 	if err != nil {
 		err = fmt.Errorf("error reading directory: %v", err)
 		return err
 	}
 
 	for _, file := range files {
-		sourceFileName := filepath.Join(origin, file.Name())
+		sourceFileName := path.Join(origin, file.Name())
 		destFileName := filepath.Join(target, file.Name())
 
 		if file.IsDir() {
@@ -55,7 +56,7 @@ func CopyDir(f embed.FS, origin, target string) error {
 			continue
 		}
 
-		fileContent, err := f.ReadFile(sourceFileName)
+		fileContent, err := fs.ReadFile(f, sourceFileName)
 		if err != nil {
 			err = fmt.Errorf("error reading file: %v", err)
 			return err
@@ -71,8 +72,8 @@ func CopyDir(f embed.FS, origin, target string) error {
 	return nil
 }
 
-func IsDirExists(f embed.FS, origin string) bool {
-	_, err := f.ReadDir(origin)
+func IsDirExists(f fs.FS, origin string) bool {
+	_, err := fs.ReadDir(f, origin)
 	if err != nil {
 		ExitWithError(err)
 		return false
@@ -43,6 +43,11 @@ func PrintSuccess(msg string) {
 	fmt.Printf("%s%s\n", colorNone, msg)
 }
 
+func PrintWarning(msg string) {
+	fmt.Printf("%s%s", colorYellow, "warning: ")
+	fmt.Printf("%s%s\n", colorNone, msg)
+}
+
 func PrintInfo(msg string) {
 	fmt.Printf("%s%s", colorYellow, "info: ")
 	fmt.Printf("%s%s\n", colorNone, msg)
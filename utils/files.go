@@ -34,3 +34,8 @@ func GetFileModTime(filePath string) (time.Time, error) {
 
 	return fileInfo.ModTime(), nil
 }
+
+func FileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
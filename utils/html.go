@@ -21,7 +21,11 @@ THE SOFTWARE.
 */
 package utils
 
-import "golang.org/x/net/html"
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
 
 func IsHtmlIdEquals(node *html.Node, id string) bool {
 	if node == nil {
@@ -61,3 +65,60 @@ func FindOrAppendAtribute(node *html.Node, key string) int {
 
 	return len(node.Attr) - 1
 }
+
+func GetHtmlAttribute(node *html.Node, key string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+
+	return "", false
+}
+
+func GetHtmlText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	text := ""
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		text += GetHtmlText(n)
+	}
+
+	return text
+}
+
+func SetHtmlAttribute(node *html.Node, key, val string) {
+	for i := range node.Attr {
+		if node.Attr[i].Key == key {
+			node.Attr[i].Val = val
+			return
+		}
+	}
+
+	node.Attr = append(node.Attr, html.Attribute{
+		Key: key,
+		Val: val,
+	})
+}
+
+func AddHtmlClass(node *html.Node, class string) {
+	for i := range node.Attr {
+		if node.Attr[i].Key == "class" {
+			for _, c := range strings.Fields(node.Attr[i].Val) {
+				if c == class {
+					return
+				}
+			}
+
+			node.Attr[i].Val = strings.TrimSpace(node.Attr[i].Val + " " + class)
+			return
+		}
+	}
+
+	node.Attr = append(node.Attr, html.Attribute{
+		Key: "class",
+		Val: class,
+	})
+}
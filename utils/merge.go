@@ -0,0 +1,122 @@
+/*
+Copyright © 2024 Milos Zivlak
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"slices"
+	"strings"
+)
+
+// Merge3 merges changes from base to ours and from base to theirs. Changes to
+// the same lines are written between conflict markers and reported with
+// conflict flag.
+func Merge3(base, ours, theirs, oursName, theirsName string) (string, bool) {
+	o := splitLines(base)
+	a := splitLines(ours)
+	b := splitLines(theirs)
+
+	matchA := matchLines(o, a)
+	matchB := matchLines(o, b)
+
+	var out []string
+	conflict := false
+
+	resolve := func(co, ca, cb []string) {
+		switch {
+		case slices.Equal(ca, cb), slices.Equal(co, cb):
+			out = append(out, ca...)
+		case slices.Equal(co, ca):
+			out = append(out, cb...)
+		default:
+			conflict = true
+			out = append(out, "<<<<<<< "+oursName)
+			out = append(out, ca...)
+			out = append(out, "=======")
+			out = append(out, cb...)
+			out = append(out, ">>>>>>> "+theirsName)
+		}
+	}
+
+	i, ia, ib := 0, 0, 0
+	for {
+		k := 0
+		for i+k < len(o) && matchA[i+k] == ia+k && matchB[i+k] == ib+k {
+			k++
+		}
+		if k > 0 {
+			out = append(out, o[i:i+k]...)
+			i, ia, ib = i+k, ia+k, ib+k
+			continue
+		}
+
+		j := i
+		for j < len(o) && (matchA[j] < 0 || matchB[j] < 0) {
+			j++
+		}
+
+		if j == len(o) {
+			resolve(o[i:], a[ia:], b[ib:])
+			break
+		}
+
+		resolve(o[i:j], a[ia:matchA[j]], b[ib:matchB[j]])
+		i, ia, ib = j, matchA[j], matchB[j]
+	}
+
+	eol := "\n"
+	if strings.Contains(ours, "\r\n") || (ours == "" && strings.Contains(theirs, "\r\n")) {
+		eol = "\r\n"
+	}
+
+	if len(out) == 0 {
+		return "", conflict
+	}
+
+	return strings.Join(out, eol) + eol, conflict
+}
+
+// matchLines returns index of matching line in b for every line in a, or -1
+// when line is not part of the longest common subsequence.
+func matchLines(a, b []string) []int {
+	lcs := lcsTable(a, b)
+
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return match
+}
@@ -27,17 +27,25 @@ import (
 	"github.com/zivlakmilos/author/utils"
 )
 
-const interval = 100 * time.Millisecond
+const (
+	interval = 100 * time.Millisecond
+
+	// expandInterval is how often source patterns are matched again to pick
+	// up added and removed files, walking project is too slow for interval.
+	expandInterval = 2 * time.Second
+)
 
 type Config struct {
-	Html bool
-	Pdf  bool
+	Html  bool
+	Pdf   bool
+	Draft bool
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Html: false,
-		Pdf:  false,
+		Html:  false,
+		Pdf:   false,
+		Draft: false,
 	}
 }
 
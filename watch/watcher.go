@@ -34,8 +34,9 @@ type watcher struct {
 	cfg     Config
 	project *data.Project
 
-	sources        map[string]time.Time
-	projectModTime time.Time
+	sources      map[string]time.Time
+	projectFiles map[string]time.Time
+	expandedAt   time.Time
 }
 
 func newWatcher(cfg Config) *watcher {
@@ -48,18 +49,32 @@ func newWatcher(cfg Config) *watcher {
 }
 
 func (w *watcher) loadProject() error {
-	project, err := data.LoadProject("project.json")
+	var project *data.Project
+	var err error
+	if w.project == nil {
+		project, err = data.OpenProject()
+	} else {
+		project, err = data.LoadProject(w.project.File)
+	}
 	if err != nil {
 		return err
 	}
 
-	modTime, err := utils.GetFileModTime("project.json")
-	if err != nil {
-		return err
+	projectFiles := map[string]time.Time{}
+	for _, file := range project.Files {
+		modTime, err := utils.GetFileModTime(file)
+		if err != nil {
+			return err
+		}
+		projectFiles[file] = modTime
 	}
 
 	w.project = project
-	w.projectModTime = modTime
+	w.project.Html.Production = false
+	if w.cfg.Draft {
+		w.project.Pdf.Draft = true
+	}
+	w.projectFiles = projectFiles
 
 	w.project.Targets = slices.DeleteFunc(w.project.Targets, func(el string) bool {
 		if el == "html" && !w.cfg.Html {
@@ -72,9 +87,7 @@ func (w *watcher) loadProject() error {
 	})
 
 	w.sources = map[string]time.Time{}
-	for _, src := range w.project.Sources {
-		w.sources[src] = time.Time{}
-	}
+	w.expandedAt = time.Time{}
 
 	return nil
 }
@@ -88,21 +101,59 @@ func (w *watcher) run() {
 }
 
 func (w *watcher) reloadProject() {
-	modTime, err := utils.GetFileModTime("project.json")
+	for file, val := range w.projectFiles {
+		modTime, err := utils.GetFileModTime(file)
+		if err != nil {
+			return
+		}
+
+		if modTime.After(val) {
+			w.loadProject()
+			return
+		}
+	}
+}
+
+func (w *watcher) updateSources() bool {
+	if time.Since(w.expandedAt) < expandInterval {
+		return false
+	}
+	w.expandedAt = time.Now()
+
+	srcs, err := w.project.ExpandSources()
 	if err != nil {
-		return
+		return false
 	}
 
-	if modTime.After(w.projectModTime) {
-		err = w.loadProject()
+	for _, key := range w.project.LanguageKeys() {
+		langSrcs, err := w.project.LanguageProject(key).ExpandSources()
 		if err != nil {
-			return
+			return false
 		}
+		srcs = append(srcs, langSrcs...)
 	}
+
+	changed := false
+	current := map[string]bool{}
+	for _, src := range srcs {
+		current[src] = true
+		if _, ok := w.sources[src]; !ok {
+			w.sources[src] = time.Time{}
+		}
+	}
+
+	for src := range w.sources {
+		if !current[src] {
+			delete(w.sources, src)
+			changed = true
+		}
+	}
+
+	return changed
 }
 
 func (w *watcher) runBuild() {
-	rebuild := false
+	rebuild := w.updateSources()
 	for key, val := range w.sources {
 		modTime, _ := utils.GetFileModTime(key)
 		if modTime.After(val) {